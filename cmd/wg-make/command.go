@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tevino/log"
+	"github.com/tevino/wg-make/config"
+)
+
+// Exit codes of wg-make.
+const (
+	exitOK = 0
+	// The command failed to run.
+	exitFailure = 1
+	// The command line is invalid.
+	exitUsage = 2
+	// The command ran but the check it performs did not pass, e.g. an invalid network description.
+	exitCheckFailed = 3
+)
+
+var (
+	errUsage       = errors.New("invalid usage")
+	errCheckFailed = errors.New("check failed")
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []*command{
+	{name: cmdInit, summary: "Create directory structure with examples", run: initExample},
+	{name: cmdRender, summary: "Render configurations of peers", run: render},
+	{name: cmdValidate, summary: "Validate network description files", run: validate},
+	{name: cmdShow, summary: "Show peers of networks", run: show},
+	{name: cmdDiff, summary: "Show changes rendering would make to configurations of peers", run: diffNetworks},
+	{name: cmdAddPeer, summary: "Add a peer to a network description file", run: addPeer},
+	{name: cmdRemovePeer, summary: "Remove a peer from a network description file", run: removePeer},
+	{name: cmdExpiring, summary: "List peers expiring soon", run: listExpiringPeers},
+	{name: cmdGraph, summary: "Print the topology of networks in DOT format", run: graph},
+	{name: cmdVerify, summary: "Verify a deployed configuration of a peer against the network description", run: verify},
+	{name: cmdImport, summary: "Import existing WireGuard configurations into a network description file", run: importConfigs},
+	{name: cmdQR, summary: "Show the config of a peer as a QR code and write it as PNG and SVG images", run: qr},
+	{name: cmdExport, summary: "Export peers and their computed configs as JSON or YAML", run: export},
+}
+
+// newFlagSet returns a FlagSet for the command, its errors are handled by runCommand.
+func newFlagSet(name string, argsUsage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s\n\nArguments:\n", os.Args[0], strings.TrimSpace(name+" [arguments] "+argsUsage))
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args, errors are wrapped as errUsage.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", errUsage, err)
+}
+
+// parseInterspersedFlags parses args with flags before or after the positional arguments, which are returned.
+func parseInterspersedFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := parseFlags(flags, args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		// Everything after "--" is positional.
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func runCommand(args []string) int {
+	name := cmdRender
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		flag.Usage()
+		return exitOK
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(args)
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.Is(err, errUsage):
+			log.Error(err)
+			return exitUsage
+		case errors.Is(err, errCheckFailed):
+			log.Error(err)
+			return exitCheckFailed
+		default:
+			log.Error(err)
+			return exitFailure
+		}
+	}
+	log.Errorf("Unknown command: %s", name)
+	flag.Usage()
+	return exitUsage
+}
+
+// activePeer returns the peer in conf, a usage error is returned if nothing is rendered for it at now.
+func activePeer(conf *config.Config, peerID string, now time.Time) (*config.Peer, error) {
+	peer, ok := conf.GetPeerByID(peerID)
+	if !ok {
+		return nil, fmt.Errorf("%w: peer %s not found in network %s", errUsage, peerID, conf.Network.ID)
+	}
+	if active, err := peer.IsActiveAt(now); err != nil {
+		return nil, err
+	} else if !active {
+		return nil, fmt.Errorf("%w: peer %s is disabled or expired, nothing is rendered for it", errUsage, peerID)
+	}
+	return peer, nil
+}
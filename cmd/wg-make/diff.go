@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/tevino/log"
+	"github.com/tevino/wg-make/diff"
+	"github.com/tevino/wg-make/rendering"
+)
+
+const cmdDiff = "diff"
+
+func diffNetworks(args []string) error {
+	var networkID string
+	flags := newFlagSet(cmdDiff, "")
+	flags.StringVar(&networkID, "network", "", "Diff only the network of given ID")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	networks, err := selectNetworks(networkID)
+	if err != nil {
+		return err
+	}
+	manifest, err := rendering.LoadManifest(dirPeers)
+	if err != nil {
+		return err
+	}
+	changed := 0
+	for _, network := range networks {
+		conf := network.conf
+		if err := conf.Validate(); err != nil {
+			return fmt.Errorf("%w: invalid network description(%s): %v", errCheckFailed, network.path, err)
+		}
+		configs, err := rendering.Render(conf, rendering.Options{})
+		if err != nil {
+			return fmt.Errorf("rendering network %s: %w", conf.Network.ID, err)
+		}
+		files := rendering.SortedFiles(configs)
+		for _, f := range files {
+			confPath := path.Join(dirPeers, f.Path)
+			nameOld := confPath
+			current, err := ioutil.ReadFile(confPath)
+			if os.IsNotExist(err) {
+				nameOld = os.DevNull
+			} else if err != nil {
+				return fmt.Errorf("reading peer config(%s): %w", confPath, err)
+			} else if rendering.IsModifiedManually(current) {
+				log.Warnf("%s was modified manually", confPath)
+			}
+			// The time of rendering always changes, it's not a change to the config.
+			d := diff.Unified(nameOld, confPath, rendering.StripGeneratedLine(current), rendering.StripGeneratedLine(f.Content), diff.DefaultContext)
+			if d != "" {
+				fmt.Print(d)
+				changed++
+			}
+		}
+		stale, err := diffRemoved(manifest.StaleFiles(conf.Network.ID, files))
+		if err != nil {
+			return err
+		}
+		changed += stale
+	}
+	if networkID == "" {
+		for _, removed := range manifest.StaleNetworks(networkIDs(networks)) {
+			stale, err := diffRemoved(manifest.StaleFiles(removed, nil))
+			if err != nil {
+				return err
+			}
+			changed += stale
+		}
+	}
+	if changed > 0 {
+		return fmt.Errorf("%w: %d peer config(s) would be changed", errCheckFailed, changed)
+	}
+	return nil
+}
+
+// diffRemoved prints the diff of removing the files at paths relative to the peers folder.
+func diffRemoved(paths []string) (int, error) {
+	removed := 0
+	for _, p := range paths {
+		confPath := path.Join(dirPeers, p)
+		current, err := ioutil.ReadFile(confPath)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return removed, fmt.Errorf("reading peer config(%s): %w", confPath, err)
+		}
+		fmt.Print(diff.Unified(confPath, os.DevNull, rendering.StripGeneratedLine(current), nil, diff.DefaultContext))
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const cmdExpiring = "expiring"
+
+func listExpiringPeers(args []string) error {
+	var networkID string
+	var days int
+	flags := newFlagSet(cmdExpiring, "")
+	flags.StringVar(&networkID, "network", "", "List only peers of the network of given ID")
+	flags.IntVar(&days, "days", 7, "List peers expiring within given days, expired peers are always listed")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	networks, err := selectNetworks(networkID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, network := range networks {
+		conf := network.conf
+		peers, err := conf.PeersExpiringWithin(now, time.Duration(days)*24*time.Hour)
+		if err != nil {
+			return fmt.Errorf("listing expiring peers of network %s: %w", conf.Network.ID, err)
+		}
+		for _, p := range peers {
+			expiry, _, _ := p.Expiry()
+			state := "expires"
+			if !now.Before(expiry) {
+				state = "expired"
+			}
+			fmt.Printf("%s\t%s\t%s at %s\n", conf.Network.ID, p.ID, state, expiry.Format("2006-01-02 15:04:05 -0700"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/rendering"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	cmdExport = "export"
+
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+func export(args []string) error {
+	var networkID, format string
+	var secrets bool
+	flags := newFlagSet(cmdExport, "")
+	flags.StringVar(&networkID, "network", "", "Export only the network of given ID")
+	flags.StringVar(&format, "format", formatJSON, "Format of the output [json|yaml]")
+	flags.BoolVar(&secrets, "secrets", false, "Include private keys and preshared keys")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if format != formatJSON && format != formatYAML {
+		return fmt.Errorf("%w: unknown format: %s", errUsage, format)
+	}
+
+	networks, err := selectNetworks(networkID)
+	if err != nil {
+		return err
+	}
+	confs := make([]*config.Config, 0, len(networks))
+	for _, network := range networks {
+		if err := network.conf.Validate(); err != nil {
+			return fmt.Errorf("%w: %s: %v", errCheckFailed, network.path, err)
+		}
+		confs = append(confs, network.conf)
+	}
+	exported, err := rendering.ExportNetworks(confs, time.Now(), rendering.ExportOptions{Secrets: secrets})
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	if format == formatYAML {
+		out, err = yaml.Marshal(exported)
+	} else {
+		out, err = json.MarshalIndent(exported, "", "  ")
+		out = append(out, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", format, err)
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cmdGraph = "graph"
+
+func graph(args []string) error {
+	var networkID string
+	flags := newFlagSet(cmdGraph, "")
+	flags.StringVar(&networkID, "network", "", "Print only the network of given ID")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	networks, err := selectNetworks(networkID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	fmt.Println("graph wg {")
+	for _, network := range networks {
+		conf := network.conf
+		peers, err := conf.ActivePeers(now)
+		if err != nil {
+			return fmt.Errorf("network %s: %w", conf.Network.ID, err)
+		}
+		node := func(peerID string) string {
+			return strconv.Quote(conf.Network.ID + "/" + peerID)
+		}
+		fmt.Printf("\tsubgraph %s {\n", strconv.Quote("cluster_"+conf.Network.ID))
+		fmt.Printf("\t\tlabel = %s;\n", strconv.Quote(conf.Network.ID+" "+conf.Network.Subnet))
+		for _, p := range peers {
+			label := []string{p.ID, p.Address}
+			shape := "ellipse"
+			if p.IsBounceServer() {
+				label = append(label, p.Endpoint)
+				shape = "box"
+			}
+			fmt.Printf("\t\t%s [label=%s, shape=%s];\n", node(p.ID), strconv.Quote(strings.Join(label, "\n")), shape)
+		}
+		// Links are symmetric, each pair is printed once.
+		for i := range peers {
+			for j := i + 1; j < len(peers); j++ {
+				if peers[i].IsLinkedTo(&peers[j]) {
+					fmt.Printf("\t\t%s -- %s;\n", node(peers[i].ID), node(peers[j].ID))
+				}
+			}
+		}
+		fmt.Println("\t}")
+	}
+	fmt.Println("}")
+	return nil
+}
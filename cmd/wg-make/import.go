@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/tevino/log"
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/config/wireguard"
+	"github.com/tevino/wg-make/importing"
+)
+
+const (
+	cmdImport = "import"
+
+	importFromWGQuick     = "wg-quick"
+	importFromWGEasy      = "wg-easy"
+	importFromWireGuardUI = "wireguard-ui"
+)
+
+func importConfigs(args []string) error {
+	var networkID, from string
+	var opts importing.ServerOptions
+	var force bool
+	flags := newFlagSet(cmdImport, "FILE...")
+	flags.StringVar(&networkID, "network", "", "ID of the network to create")
+	flags.StringVar(&from, "from", importFromWGQuick, "Format of the files [wg-quick|wg-easy|wireguard-ui], "+
+		"wg-quick configs of peers, wg0.json of wg-easy or the database folder of wireguard-ui")
+	flags.StringVar(&opts.Subnet, "subnet", "", "Subnet of the network, inferred if omitted")
+	flags.StringVar(&opts.Endpoint, "endpoint", "", "Endpoint of the server of wg-easy or wireguard-ui, e.g. vpn.example.com:51820")
+	flags.StringVar(&opts.PublicInterface, "public-interface", "", "Network interface of the server of wg-easy or wireguard-ui connecting to the Internet")
+	flags.IntVar(&opts.PersistentKeepalive, "keepalive", 0, "PersistentKeepalive of clients of wg-easy or wireguard-ui")
+	flags.BoolVar(&force, "force", false, "Overwrite the network description file if it exists")
+	files, err := parseInterspersedFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if networkID == "" || len(files) == 0 {
+		return fmt.Errorf("%w: -network and at least one file are required", errUsage)
+	}
+	if from != importFromWGQuick && len(files) > 1 {
+		return fmt.Errorf("%w: only one file is accepted from %s", errUsage, from)
+	}
+	networkPath := path.Join(dirNetworks, networkID+extConf)
+	if _, err := os.Stat(networkPath); err == nil && !force {
+		return fmt.Errorf("%w: %s exists, use -force to overwrite it", errUsage, networkPath)
+	}
+
+	var r *importing.Result
+	switch from {
+	case importFromWGQuick:
+		r, err = importWGQuick(networkID, opts.Subnet, files)
+	case importFromWGEasy:
+		if opts.Endpoint == "" {
+			return fmt.Errorf("%w: -endpoint is required as wg-easy doesn't store it", errUsage)
+		}
+		var data []byte
+		data, err = ioutil.ReadFile(files[0])
+		if err != nil {
+			return fmt.Errorf("reading file(%s): %w", files[0], err)
+		}
+		r, err = importing.FromWGEasy(networkID, data, opts)
+	case importFromWireGuardUI:
+		r, err = importing.FromWireGuardUI(networkID, files[0], opts)
+	default:
+		return fmt.Errorf("%w: unknown format: %s", errUsage, from)
+	}
+	if err != nil {
+		return err
+	}
+	for _, problem := range r.Problems {
+		log.Warnf("%s", problem)
+	}
+	return writeImportedNetwork(networkPath, r)
+}
+
+func importWGQuick(networkID string, subnet string, files []string) (*importing.Result, error) {
+	sources := make([]importing.Source, 0, len(files))
+	for _, filePath := range files {
+		f, err := wireguard.LoadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		wgConf, unknown, err := f.Config()
+		if err != nil {
+			return nil, fmt.Errorf("reading config(%s): %w", filePath, err)
+		}
+		for _, key := range unknown {
+			log.Warnf("%s: %s, ignored", filePath, key)
+		}
+		name := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+		sources = append(sources, importing.Source{Name: name, Config: wgConf})
+	}
+	return importing.FromWireGuard(networkID, subnet, sources)
+}
+
+// writeImportedNetwork writes the network description built by an importer.
+func writeImportedNetwork(networkPath string, r *importing.Result) error {
+	if err := os.MkdirAll(dirNetworks, fileModeSensitive); err != nil {
+		return fmt.Errorf("creating networks dir(%s): %w", dirNetworks, err)
+	}
+	if err := ioutil.WriteFile(networkPath, config.FormatNetwork(r.Config), fileModeSensitive); err != nil {
+		return fmt.Errorf("writing file(%s): %w", networkPath, err)
+	}
+	log.Infof("Imported %d peer(s) into %s", len(r.Config.Peers), networkPath)
+	if len(r.Problems) > 0 {
+		fmt.Printf("%d problem(s) above could not be reconciled, please review %s before rendering\n", len(r.Problems), networkPath)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/tevino/log"
+	"github.com/tevino/wg-make/example"
+)
+
+const cmdInit = "init"
+
+func initExample(args []string) error {
+	var assumeYes bool
+	flags := newFlagSet(cmdInit, "")
+	flags.BoolVar(&assumeYes, "y", false, "Create the directory structure without confirmation even if the directory is not empty")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(".")
+	if err != nil {
+		return fmt.Errorf("reading current directory: %w", err)
+	}
+	if len(files) > 0 && !assumeYes {
+		fmt.Print(`The current diectory is not empty, are you sure to create directory structure here?
+You may want to cd into a new directory first (y/N): `)
+		input := bufio.NewReader(os.Stdin)
+		answer, _, err := input.ReadRune()
+		if err != nil {
+			return fmt.Errorf("reading input from stdin: %w", err)
+		}
+		if answer != 'y' && answer != 'Y' {
+			return nil
+		}
+	}
+	infoTitlef("Generating directory structure with examples")
+	log.Info("Creating networks directory")
+	err = os.MkdirAll(dirNetworks, fileModeSensitive)
+	if err != nil {
+		return fmt.Errorf("creating directory(%s): %w", dirNetworks, err)
+	}
+	log.Info("Creating example network configuration")
+	filename := path.Join(dirNetworks, filenameExampleConf)
+	err = ioutil.WriteFile(filename, []byte(example.FileConfExample), fileModeSensitive)
+	if err != nil {
+		return fmt.Errorf("creating file(%s): %w", filename, err)
+	}
+	return nil
+}
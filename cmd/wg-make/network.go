@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/tevino/wg-make/config"
+)
+
+// networkFile is a network description file with its content loaded.
+type networkFile struct {
+	path string
+	conf *config.Config
+}
+
+func loadNetworks() ([]networkFile, error) {
+	files, err := ioutil.ReadDir(dirNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("reading networks dir(%s): %w", dirNetworks, err)
+	}
+
+	networks := []networkFile{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), extConf) {
+			continue
+		}
+
+		pathNetworkConf := path.Join(dirNetworks, f.Name())
+		conf, err := config.LoadConfigFromFile(pathNetworkConf)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected config file(%s): %w", pathNetworkConf, err)
+		}
+		networks = append(networks, networkFile{path: pathNetworkConf, conf: conf})
+	}
+	return networks, nil
+}
+
+func networkIDs(networks []networkFile) []string {
+	ids := make([]string, 0, len(networks))
+	for _, network := range networks {
+		ids = append(ids, network.conf.Network.ID)
+	}
+	return ids
+}
+
+// selectNetworks returns all networks, or only the one of given ID if it's not empty.
+func selectNetworks(networkID string) ([]networkFile, error) {
+	if networkID == "" {
+		return loadNetworks()
+	}
+	network, err := findNetwork(networkID)
+	if err != nil {
+		return nil, err
+	}
+	return []networkFile{network}, nil
+}
+
+// findNetwork returns the description file of given network.
+func findNetwork(networkID string) (networkFile, error) {
+	networks, err := loadNetworks()
+	if err != nil {
+		return networkFile{}, err
+	}
+	for _, network := range networks {
+		if network.conf.Network.ID == networkID {
+			return network, nil
+		}
+	}
+	return networkFile{}, fmt.Errorf("network %s not found in %s", networkID, dirNetworks)
+}
@@ -2,23 +2,24 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
 
 	"github.com/tevino/log"
 )
 
 type opt struct {
-	logLevel    log.Level
-	isDebug     bool
-	needExample bool
-	needClean   bool
+	logLevel log.Level
+	isDebug  bool
+	workdir  string
 }
 
 func (o *opt) Parse() *opt {
 	var logLevelStr string
 	flag.StringVar(&logLevelStr, "log", "INFO", "Log level [DEBUG|INFO|WARNING|FATAL]")
 	flag.BoolVar(&o.isDebug, "debug", false, "debug mode, alias of -log DEBUG")
-	flag.BoolVar(&o.needExample, "example", false, "Create directory structure with examples in the current directory")
-	flag.BoolVar(&o.needClean, "clean", false, "Remove all files in the peers folder before generating")
+	flag.StringVar(&o.workdir, "workdir", "", "Directory containing networks and peers, the current directory if omitted")
+	flag.Usage = usage
 	flag.Parse()
 
 	o.logLevel = log.LevelFromString(logLevelStr)
@@ -27,3 +28,14 @@ func (o *opt) Parse() *opt {
 	}
 	return o
 }
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [options] <command> [arguments]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nThe render command is run if no command is given.\n")
+	fmt.Fprintf(out, "Run '%s <command> -h' for arguments of a command.\n\nOptions:\n", os.Args[0])
+	flag.PrintDefaults()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	"github.com/tevino/log"
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/config/wireguard"
+	"github.com/tevino/wg-make/rendering"
+)
+
+const (
+	cmdAddPeer    = "add-peer"
+	cmdRemovePeer = "remove-peer"
+
+	roleClient = "client"
+	roleServer = "server"
+)
+
+func addPeer(args []string) error {
+	var peer config.Peer
+	var networkID, role string
+	flags := newFlagSet(cmdAddPeer, "")
+	flags.StringVar(&networkID, "network", "", "ID of the network to add the peer to")
+	flags.StringVar(&peer.ID, "id", "", "ID of the new peer")
+	flags.StringVar(&role, "role", roleClient, "Role of the new peer [client|server]")
+	flags.StringVar(&peer.Address, "address", "", "WireGuard IP address of the peer, allocated from the subnet if omitted")
+	flags.StringVar(&peer.LocalSubnets, "local-subnets", "", "Subnets in which the peer already resides")
+	flags.StringVar(&peer.PublicKey, "public-key", "", "PublicKey of a peer whose private key never leaves the device, a key pair is generated if omitted")
+	flags.StringVar(&peer.Endpoint, "endpoint", "", "Publicly accessible address of a server, e.g. example.com:51820")
+	flags.IntVar(&peer.ListenPort, "listen-port", 0, "Port for a server to listen, the port of the endpoint if omitted")
+	flags.StringVar(&peer.AllowedIPs, "allowed-ips", "", "Subnets a server is capable of routing traffic for")
+	flags.StringVar(&peer.PublicInterface, "public-interface", "", "Network interface of a server connecting to the Internet")
+	flags.StringVar(&peer.OS, "os", "", "Operating System of the peer, e.g. Linux")
+	flags.IntVar(&peer.PersistentKeepalive, "keepalive", 0, "PersistentKeepalive if the peer is behind a NAT")
+	flags.StringVar(&peer.ExpiresAt, "expires-at", "", "Time after which the peer is left out of the network")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if networkID == "" || peer.ID == "" {
+		return fmt.Errorf("%w: both -network and -id are required", errUsage)
+	}
+	switch role {
+	case roleClient:
+	case roleServer:
+		if peer.Endpoint == "" || peer.PublicInterface == "" {
+			return fmt.Errorf("%w: both -endpoint and -public-interface are required for a server", errUsage)
+		}
+		if peer.ListenPort == 0 {
+			_, port, err := net.SplitHostPort(peer.Endpoint)
+			if err != nil {
+				return fmt.Errorf("%w: invalid endpoint(%s): %v", errUsage, peer.Endpoint, err)
+			}
+			peer.ListenPort, err = strconv.Atoi(port)
+			if err != nil {
+				return fmt.Errorf("%w: invalid port of endpoint(%s): %v", errUsage, peer.Endpoint, err)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown role: %s", errUsage, role)
+	}
+
+	network, err := findNetwork(networkID)
+	if err != nil {
+		return err
+	}
+	conf := network.conf
+	// The same peer could be in other networks, its configs are rendered per network as peers/<ID>/wg-<network>.conf.
+	if _, ok := conf.GetPeerByID(peer.ID); ok {
+		return fmt.Errorf("%w: peer %s already exists in network %s", errUsage, peer.ID, networkID)
+	}
+
+	if peer.Address == "" {
+		peer.Address, err = conf.NextFreeAddress()
+		if err != nil {
+			return fmt.Errorf("allocating address in network %s: %w", networkID, err)
+		}
+	}
+	if peer.PublicKey == "" {
+		peer.PrivateKey, peer.PublicKey, err = wireguard.GenerateKey()
+		if err != nil {
+			return fmt.Errorf("generating keys: %w", err)
+		}
+	}
+	if err := peer.Validate(); err != nil {
+		return fmt.Errorf("%w: invalid peer: %v", errUsage, err)
+	}
+
+	src, err := ioutil.ReadFile(network.path)
+	if err != nil {
+		return fmt.Errorf("reading file(%s): %w", network.path, err)
+	}
+	err = ioutil.WriteFile(network.path, config.AppendPeer(src, &peer), fileModeSensitive)
+	if err != nil {
+		return fmt.Errorf("writing file(%s): %w", network.path, err)
+	}
+	log.Infof("Added peer %s with address %s to %s", peer.ID, peer.Address, network.path)
+	fmt.Printf("The config of peer %s will be written to %s\n", peer.ID, rendering.PeerConfigPath(dirPeers, networkID, peer.ID))
+	return nil
+}
+
+func removePeer(args []string) error {
+	var networkID, peerID string
+	flags := newFlagSet(cmdRemovePeer, "")
+	flags.StringVar(&networkID, "network", "", "ID of the network to remove the peer from")
+	flags.StringVar(&peerID, "id", "", "ID of the peer to remove")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if networkID == "" || peerID == "" {
+		return fmt.Errorf("%w: both -network and -id are required", errUsage)
+	}
+	network, err := findNetwork(networkID)
+	if err != nil {
+		return err
+	}
+	src, err := ioutil.ReadFile(network.path)
+	if err != nil {
+		return fmt.Errorf("reading file(%s): %w", network.path, err)
+	}
+	dst, err := config.RemovePeer(src, peerID)
+	if err != nil {
+		return fmt.Errorf("%w: removing peer from %s: %v", errUsage, network.path, err)
+	}
+	err = ioutil.WriteFile(network.path, dst, fileModeSensitive)
+	if err != nil {
+		return fmt.Errorf("writing file(%s): %w", network.path, err)
+	}
+	log.Infof("Removed peer %s from %s", peerID, network.path)
+	// Rendering prunes the configs of the peer, which are checked for manual edits and recorded in the manifest.
+	fmt.Printf("The configs of peer %s will be removed from %s by: wg-make render\n", peerID, dirPeers)
+	return nil
+}
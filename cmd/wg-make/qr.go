@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tevino/log"
+	"github.com/tevino/wg-make/rendering"
+)
+
+const cmdQR = "qr"
+
+func qr(args []string) error {
+	var networkID, peerID string
+	flags := newFlagSet(cmdQR, "")
+	flags.StringVar(&networkID, "network", "", "ID of the network, could be omitted if the peer is only in one network")
+	flags.StringVar(&peerID, "peer", "", "ID of the peer whose config is encoded")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if peerID == "" {
+		return fmt.Errorf("%w: -peer is required", errUsage)
+	}
+
+	network, err := findNetworkOfPeer(networkID, peerID)
+	if err != nil {
+		return err
+	}
+	conf := network.conf
+	if err := conf.Validate(); err != nil {
+		return fmt.Errorf("%w: %s: %v", errCheckFailed, network.path, err)
+	}
+	now := time.Now()
+	if _, err := activePeer(conf, peerID, now); err != nil {
+		return err
+	}
+	content, err := rendering.RenderQRContent(conf, peerID, now)
+	if err != nil {
+		return err
+	}
+	code, err := rendering.NewQRCode(content)
+	if err != nil {
+		return fmt.Errorf("the config of peer %s is too large(%d bytes) for a QR code: %w", peerID, len(content), err)
+	}
+	if code.Version() > rendering.MaxScannableQRVersion {
+		log.Warnf("The config of peer %s is %d bytes, its QR code of version %d might be too dense to be scanned",
+			peerID, len(content), code.Version())
+	}
+	fmt.Print(code.Terminal())
+
+	paths, err := code.WriteImages(rendering.PeerConfigPath(dirPeers, conf.Network.ID, peerID))
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		log.Infof("Writing QR code: %s", p)
+	}
+	return nil
+}
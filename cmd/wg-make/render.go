@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/tevino/log"
+	"github.com/tevino/wg-make/diff"
+	"github.com/tevino/wg-make/rendering"
+)
+
+const cmdRender = "render"
+
+func render(args []string) error {
+	var networkID string
+	var needClean bool
+	var tarPath string
+	var opts rendering.Options
+	flags := newFlagSet(cmdRender, "")
+	flags.StringVar(&networkID, "network", "", "Render only the network of given ID")
+	flags.BoolVar(&needClean, "clean", false, "Remove all files in the peers folder before generating, unnecessary since stale files are pruned")
+	flags.BoolVar(&opts.Force, "force", false, "Overwrite configs even if they were modified manually")
+	flags.StringVar(&tarPath, "tar", "", "Write configs into a tar archive at given path instead of the peers folder")
+	flags.BoolVar(&opts.Timestamp, "timestamp", false, "Record the time of rendering in configs instead of the hash of the network description")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if needClean && networkID != "" {
+		return fmt.Errorf("%w: -clean removes configs of all networks, it can't be used with -network", errUsage)
+	}
+	if needClean && tarPath != "" {
+		return fmt.Errorf("%w: -clean can't be used with -tar", errUsage)
+	}
+
+	networks, err := selectNetworks(networkID)
+	if err != nil {
+		return err
+	}
+	if needClean {
+		log.Warn("Cleaning peers folder")
+		if err := os.RemoveAll(dirPeers); err != nil {
+			return fmt.Errorf("removing folder(%s): %w", dirPeers, err)
+		}
+	}
+	if tarPath != "" {
+		return renderTar(networks, tarPath, opts)
+	}
+	sink := &rendering.DirSink{Dir: dirPeers, Force: opts.Force}
+	for _, network := range networks {
+		conf := network.conf
+		if err := conf.Validate(); err != nil {
+			return fmt.Errorf("%w: invalid network description(%s): %v", errCheckFailed, network.path, err)
+		}
+		infoTitlef("Found %d Peer(s) in network %s", len(conf.Peers), conf.Network.ID)
+		err := rendering.RenderTo(conf, sink, opts)
+		if errEdit := printManualEdits(err); errEdit != nil {
+			return fmt.Errorf("%w: network %s: %v, move the changes into %s then render again, or overwrite them with -force",
+				errCheckFailed, conf.Network.ID, errEdit, network.path)
+		}
+		if err != nil {
+			return fmt.Errorf("rendering network %s: %w", conf.Network.ID, err)
+		}
+	}
+	// Networks not rendered are only known to be removed when all networks are rendered.
+	if networkID == "" {
+		err := sink.Prune(networkIDs(networks))
+		if errEdit := printManualEdits(err); errEdit != nil {
+			return fmt.Errorf("%w: pruning removed networks: %v, remove them manually, or with -force", errCheckFailed, errEdit)
+		}
+		if err != nil {
+			return fmt.Errorf("pruning removed networks: %w", err)
+		}
+	}
+	return nil
+}
+
+// printManualEdits prints the changes to be discarded if err is a *rendering.ManualEditError, which is returned.
+func printManualEdits(err error) *rendering.ManualEditError {
+	var errEdit *rendering.ManualEditError
+	if !errors.As(err, &errEdit) {
+		return nil
+	}
+	for _, edit := range errEdit.Edits {
+		confPath := path.Join(dirPeers, edit.Path)
+		label := confPath + " (rendered)"
+		if edit.Rendered == nil {
+			label = confPath + " (removed)"
+		}
+		fmt.Print(diff.Unified(confPath, label, edit.Current, edit.Rendered, diff.DefaultContext))
+	}
+	return errEdit
+}
+
+func renderTar(networks []networkFile, tarPath string, opts rendering.Options) (err error) {
+	f, err := os.OpenFile(tarPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("creating archive(%s): %w", tarPath, err)
+	}
+	defer func() {
+		if errClose := f.Close(); err == nil && errClose != nil {
+			err = fmt.Errorf("closing archive(%s): %w", tarPath, errClose)
+		}
+	}()
+
+	sink := rendering.NewTarSink(f)
+	for _, network := range networks {
+		conf := network.conf
+		if err := conf.Validate(); err != nil {
+			return fmt.Errorf("%w: invalid network description(%s): %v", errCheckFailed, network.path, err)
+		}
+		infoTitlef("Found %d Peer(s) in network %s", len(conf.Peers), conf.Network.ID)
+		if err := rendering.RenderTo(conf, sink, opts); err != nil {
+			return fmt.Errorf("rendering network %s: %w", conf.Network.ID, err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		return fmt.Errorf("finishing archive(%s): %w", tarPath, err)
+	}
+	log.Infof("Configs written to %s", tarPath)
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/tevino/wg-make/config"
+)
+
+const cmdShow = "show"
+
+func role(p *config.Peer) string {
+	r := "client"
+	if p.IsBounceServer() {
+		r = "bounce server"
+	}
+	if p.Disabled {
+		r += " (disabled)"
+	}
+	return r
+}
+
+func show(args []string) error {
+	var networkID string
+	flags := newFlagSet(cmdShow, "")
+	flags.StringVar(&networkID, "network", "", "Show only the network of given ID")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	networks, err := selectNetworks(networkID)
+	if err != nil {
+		return err
+	}
+	for i, network := range networks {
+		conf := network.conf
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Network %s (%s) from %s\n\n", conf.Network.ID, conf.Network.Subnet, network.path)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tROLE\tADDRESS\tENDPOINT\tLOCAL SUBNETS\tALLOWED IPS\tEXPIRES AT")
+		for i := range conf.Peers {
+			p := &conf.Peers[i]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, role(p), p.Address,
+				orDash(p.Endpoint), orDash(p.LocalSubnets), orDash(p.AllowedIPs), orDash(p.ExpiresAt))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tevino/log"
+)
+
+const cmdValidate = "validate"
+
+func validate(args []string) error {
+	var networkID string
+	flags := newFlagSet(cmdValidate, "")
+	flags.StringVar(&networkID, "network", "", "Validate only the network of given ID")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	networks, err := selectNetworks(networkID)
+	if err != nil {
+		return err
+	}
+	invalid := 0
+	for _, network := range networks {
+		if err := network.conf.Validate(); err != nil {
+			log.Errorf("%s: %v", network.path, err)
+			invalid++
+			continue
+		}
+		log.Infof("%s: OK", network.path)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%w: %d of %d network description file(s) invalid", errCheckFailed, invalid, len(networks))
+	}
+	return nil
+}
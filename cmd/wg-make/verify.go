@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/tevino/log"
+	"github.com/tevino/wg-make/config/wireguard"
+	"github.com/tevino/wg-make/rendering"
+)
+
+const cmdVerify = "verify"
+
+func verify(args []string) error {
+	var networkID, peerID, against string
+	flags := newFlagSet(cmdVerify, "")
+	flags.StringVar(&networkID, "network", "", "ID of the network, could be omitted if the peer is only in one network")
+	flags.StringVar(&peerID, "peer", "", "ID of the peer to verify")
+	flags.StringVar(&against, "against", "", "Config deployed on the peer, or the output of `wg showconf`")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if peerID == "" || against == "" {
+		return fmt.Errorf("%w: both -peer and -against are required", errUsage)
+	}
+
+	network, err := findNetworkOfPeer(networkID, peerID)
+	if err != nil {
+		return err
+	}
+	conf := network.conf
+	if err := conf.Validate(); err != nil {
+		return fmt.Errorf("%w: %s: %v", errCheckFailed, network.path, err)
+	}
+	now := time.Now()
+	peer, err := activePeer(conf, peerID, now)
+	if err != nil {
+		return err
+	}
+	expected, err := rendering.BuildPeerConfig(conf, peerID, now)
+	if err != nil {
+		return err
+	}
+
+	f, err := wireguard.LoadFile(against)
+	if err != nil {
+		return err
+	}
+	actual, unknown, err := f.Config()
+	if err != nil {
+		return fmt.Errorf("reading config(%s): %w", against, err)
+	}
+	for _, key := range unknown {
+		log.Warnf("%s: %s, ignored", against, key)
+	}
+
+	diffs := wireguard.Compare(expected, actual, wireguard.CompareOptions{
+		LookupHost:       net.LookupHost,
+		IgnorePrivateKey: peer.IsPublicKeyOnly(),
+	})
+	if len(diffs) == 0 {
+		log.Infof("%s matches peer %s in network %s", against, peerID, conf.Network.ID)
+		return nil
+	}
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	return fmt.Errorf("%w: %d difference(s) found between %s and peer %s in network %s",
+		errCheckFailed, len(diffs), against, peerID, conf.Network.ID)
+}
+
+// findNetworkOfPeer returns the network of given ID, or the only network containing the peer if networkID is empty.
+func findNetworkOfPeer(networkID string, peerID string) (networkFile, error) {
+	networks, err := selectNetworks(networkID)
+	if err != nil {
+		return networkFile{}, err
+	}
+	found := []networkFile{}
+	for _, network := range networks {
+		if _, ok := network.conf.GetPeerByID(peerID); ok {
+			found = append(found, network)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return networkFile{}, fmt.Errorf("%w: peer %s not found", errUsage, peerID)
+	case 1:
+		return found[0], nil
+	}
+	return networkFile{}, fmt.Errorf("%w: peer %s is in %d networks, please specify one with -network", errUsage, peerID, len(found))
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"net"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/tevino/wg-make/config/wireguard"
 	"gopkg.in/ini.v1"
@@ -15,6 +17,36 @@ type Config struct {
 	Peers   []Peer `ini:"Peer,,,nonunique"`
 }
 
+// ActivePeers returns the peers that are neither disabled nor expired at given time.
+func (p *Config) ActivePeers(now time.Time) ([]Peer, error) {
+	peers := []Peer{}
+	for _, peer := range p.Peers {
+		active, err := peer.IsActiveAt(now)
+		if err != nil {
+			return nil, err
+		}
+		if active {
+			peers = append(peers, peer)
+		}
+	}
+	return peers, nil
+}
+
+// PeersExpiringWithin returns the peers expiring before now+d, including the expired ones.
+func (p *Config) PeersExpiringWithin(now time.Time, d time.Duration) ([]Peer, error) {
+	peers := []Peer{}
+	for _, peer := range p.Peers {
+		expiry, ok, err := peer.Expiry()
+		if err != nil {
+			return nil, err
+		}
+		if ok && expiry.Before(now.Add(d)) {
+			peers = append(peers, peer)
+		}
+	}
+	return peers, nil
+}
+
 // GetPeerByID returns Peer of given ID.
 func (p *Config) GetPeerByID(id string) (*Peer, bool) {
 	for i, peer := range p.Peers {
@@ -39,6 +71,11 @@ type Peer struct {
 	LocalSubnets        string `int:"LocalSubnets"`
 	PublicInterface     string `ini:"PublicInterface,omitempty"`
 	OS                  string `ini:"OS,omitempty"`
+	ExpiresAt           string `ini:"ExpiresAt,omitempty"`
+	Disabled            bool   `ini:"Disabled,omitempty"`
+	Output              string `ini:"Output,omitempty"`
+	InterfaceName       string `ini:"InterfaceName,omitempty"`
+	TrustedSSIDs        string `ini:"TrustedSSIDs,omitempty"`
 }
 
 // IsBounceServer returns true if the peer is capable of traffic relaying.
@@ -46,9 +83,29 @@ func (p *Peer) IsBounceServer() bool {
 	return p.Endpoint != "" && p.PublicInterface != ""
 }
 
-// All OS types, currently only used to distinguish Linux.
+// IsLinkedTo returns true if the peer has the other one in its config.
+//
+// Bounce servers should have all peers in its config.
+// Client peer only need the bounce server peers.
+func (p *Peer) IsLinkedTo(other *Peer) bool {
+	return p.IsBounceServer() || other.IsBounceServer()
+}
+
+// IsPublicKeyOnly returns true if the private key of the peer is generated and kept on the device.
+func (p *Peer) IsPublicKeyOnly() bool {
+	return p.PrivateKey == "" && p.PublicKey != ""
+}
+
+// All OS types, used to decide how to enable packet forwarding and the default formats of rendered configs.
 const (
-	OSLinux = "Linux"
+	OSLinux    = "Linux"
+	OSOpenWrt  = "OpenWrt"
+	OSRouterOS = "RouterOS"
+	OSVyOS     = "VyOS"
+	OSOPNsense = "OPNsense"
+	OSNixOS    = "NixOS"
+	OSiOS      = "iOS"
+	OSMacOS    = "macOS"
 )
 
 // IsLinux returns true if OS is Linux.
@@ -56,8 +113,161 @@ func (p *Peer) IsLinux() bool {
 	return p.OS == OSLinux
 }
 
+// All formats of rendered configs, see Outputs.
+const (
+	OutputWGQuick        = "wg-quick"
+	OutputNetworkd       = "networkd"
+	OutputNetworkManager = "networkmanager"
+	OutputOpenWrt        = "openwrt"
+	OutputRouterOS       = "routeros"
+	OutputVyOS           = "vyos"
+	OutputOPNsense       = "opnsense"
+	OutputNixOS          = "nixos"
+	OutputMobileConfig   = "mobileconfig"
+)
+
+var knownOutputs = []string{
+	OutputWGQuick, OutputNetworkd, OutputNetworkManager, OutputOpenWrt, OutputRouterOS, OutputVyOS, OutputOPNsense, OutputNixOS,
+	OutputMobileConfig,
+}
+
+// VyOS only accepts names of WireGuard interfaces like wg0.
+var reVyOSInterface = regexp.MustCompile(`^wg[0-9]+$`)
+
+// Outputs returns the formats the configs of the peer are rendered in, a comma-separated list in Output.
+//
+// Configs are rendered for wg-quick if Output is empty, unless OS is OpenWrt, RouterOS, VyOS, OPNsense, NixOS, iOS or macOS.
+func (p *Peer) Outputs() []string {
+	outputs := []string{}
+	for _, output := range strings.Split(p.Output, ",") {
+		if output = strings.TrimSpace(output); output != "" {
+			outputs = append(outputs, output)
+		}
+	}
+	if len(outputs) > 0 {
+		return outputs
+	}
+	switch p.OS {
+	case OSOpenWrt:
+		return []string{OutputOpenWrt}
+	case OSRouterOS:
+		return []string{OutputRouterOS}
+	case OSVyOS:
+		return []string{OutputVyOS}
+	case OSOPNsense:
+		return []string{OutputOPNsense}
+	case OSNixOS:
+		return []string{OutputNixOS}
+	case OSiOS, OSMacOS:
+		return []string{OutputMobileConfig}
+	}
+	return []string{OutputWGQuick}
+}
+
+// Layouts accepted by ExpiresAt, a date without time means 00:00 of that day in local time.
+var expiryLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Expiry returns the parsed ExpiresAt, ok is false if ExpiresAt is not set.
+func (p *Peer) Expiry() (expiry time.Time, ok bool, err error) {
+	if p.ExpiresAt == "" {
+		return time.Time{}, false, nil
+	}
+	for _, layout := range expiryLayouts {
+		expiry, err = time.ParseInLocation(layout, p.ExpiresAt, time.Local)
+		if err == nil {
+			return expiry, true, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid ExpiresAt(%s) of peer(%s): %w", p.ExpiresAt, p.ID, err)
+}
+
+// IsExpiredAt returns true if the peer is no longer part of the network at given time.
+func (p *Peer) IsExpiredAt(now time.Time) (bool, error) {
+	expiry, ok, err := p.Expiry()
+	if err != nil || !ok {
+		return false, err
+	}
+	return !now.Before(expiry), nil
+}
+
+// IsActiveAt returns true if the peer is part of the network at given time, i.e. neither disabled nor expired.
+func (p *Peer) IsActiveAt(now time.Time) (bool, error) {
+	expired, err := p.IsExpiredAt(now)
+	if err != nil {
+		return false, err
+	}
+	return !p.Disabled && !expired, nil
+}
+
+// PresharedKeyWith returns the PresharedKey of the link between the peer and the other one.
+//
+// The PresharedKey of a client peer is used on its links to bounce servers,
+// bounce servers only use PresharedKey between themselves if they have the same one.
+func (p *Peer) PresharedKeyWith(other *Peer) string {
+	switch {
+	case !p.IsBounceServer():
+		return p.PresharedKey
+	case !other.IsBounceServer():
+		return other.PresharedKey
+	case p.PresharedKey == other.PresharedKey:
+		return p.PresharedKey
+	}
+	return ""
+}
+
 const ipv4Bits = 32
 
+// NextFreeAddress returns the first address within the Subnet that is not taken by any peer.
+func (p *Config) NextFreeAddress() (string, error) {
+	_, subnet, err := net.ParseCIDR(p.Network.Subnet)
+	if err != nil {
+		return "", fmt.Errorf("invalid subnet(%s): %w", p.Network.Subnet, err)
+	}
+	taken := map[string]bool{}
+	for _, peer := range p.Peers {
+		if ip, _, err := net.ParseCIDR(peer.Address); err == nil {
+			taken[ip.String()] = true
+		}
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := subnet.IP.To4(); ip4 != nil {
+		subnet.IP = ip4
+		bits = ipv4Bits
+	}
+	broadcast := lastIP(subnet)
+	// The network address and the broadcast address are never assigned.
+	for ip := nextIP(subnet.IP); subnet.Contains(ip) && !ip.Equal(broadcast); ip = nextIP(ip) {
+		if !taken[ip.String()] {
+			return fmt.Sprintf("%s/%d", ip, bits), nil
+		}
+	}
+	return "", fmt.Errorf("no free address left in subnet(%s)", p.Network.Subnet)
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+func lastIP(subnet *net.IPNet) net.IP {
+	last := make(net.IP, len(subnet.IP))
+	for i := range subnet.IP {
+		last[i] = subnet.IP[i] | ^subnet.Mask[i]
+	}
+	return last
+}
+
 func isIPInSubnets(address string, subnets []string) bool {
 	ip, _, err := net.ParseCIDR(address)
 	if err != nil {
@@ -99,11 +309,86 @@ func (p *Peer) AllowedIPsForPeer(peer *Peer) string {
 
 // Validate returns the first error when validating the Peer.
 func (p *Peer) Validate() error {
+	if p.PublicKey == "" {
+		return fmt.Errorf("missing PublicKey of peer(%s)", p.ID)
+	}
 	if _, _, err := net.ParseCIDR(p.Address); err != nil && p.Address != "" {
 		return fmt.Errorf("invalid address(%s): %w", p.Address, err)
 	}
-	if _, _, err := net.ParseCIDR(p.AllowedIPs); err != nil && p.AllowedIPs != "" {
-		return fmt.Errorf("invalid AllowedIPs(%s): %w", p.Address, err)
+	if err := validateSubnets(p.AllowedIPs); err != nil {
+		return fmt.Errorf("invalid AllowedIPs(%s): %w", p.AllowedIPs, err)
+	}
+	if err := validateSubnets(p.LocalSubnets); err != nil {
+		return fmt.Errorf("invalid LocalSubnets(%s): %w", p.LocalSubnets, err)
+	}
+	if _, _, err := p.Expiry(); err != nil {
+		return err
+	}
+	for _, output := range p.Outputs() {
+		if !isKnownOutput(output) {
+			return fmt.Errorf("unknown Output(%s), should be one of %s", output, strings.Join(knownOutputs, ", "))
+		}
+		if output == OutputVyOS && !reVyOSInterface.MatchString(p.InterfaceName) {
+			return fmt.Errorf("invalid InterfaceName(%s), VyOS requires a name like wg0", p.InterfaceName)
+		}
+	}
+	return nil
+}
+
+func isKnownOutput(output string) bool {
+	for _, known := range knownOutputs {
+		if output == known {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSubnets(subnets string) error {
+	for _, subnet := range strings.Split(subnets, ",") {
+		if subnet == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate returns the first error when validating the Config.
+func (p *Config) Validate() error {
+	if p.Network.ID == "" {
+		return fmt.Errorf("missing ID of network")
+	}
+	_, subnet, err := net.ParseCIDR(p.Network.Subnet)
+	if err != nil {
+		return fmt.Errorf("invalid Subnet(%s) of network(%s): %w", p.Network.Subnet, p.Network.ID, err)
+	}
+	ids := map[string]bool{}
+	addresses := map[string]string{}
+	for _, peer := range p.Peers {
+		if peer.ID == "" {
+			return fmt.Errorf("missing ID of peer with PublicKey(%s)", peer.PublicKey)
+		}
+		if ids[peer.ID] {
+			return fmt.Errorf("duplicated peer(%s)", peer.ID)
+		}
+		ids[peer.ID] = true
+		if err := peer.Validate(); err != nil {
+			return fmt.Errorf("peer(%s): %w", peer.ID, err)
+		}
+		if peer.Address == "" {
+			return fmt.Errorf("missing Address of peer(%s)", peer.ID)
+		}
+		ip, _, _ := net.ParseCIDR(peer.Address)
+		if !subnet.Contains(ip) {
+			return fmt.Errorf("address(%s) of peer(%s) is out of subnet(%s)", peer.Address, peer.ID, p.Network.Subnet)
+		}
+		if other, ok := addresses[ip.String()]; ok {
+			return fmt.Errorf("address(%s) of peer(%s) is taken by peer(%s)", peer.Address, peer.ID, other)
+		}
+		addresses[ip.String()] = peer.ID
 	}
 	return nil
 }
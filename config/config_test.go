@@ -3,6 +3,7 @@ package config
 import (
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/tevino/wg-make/example"
@@ -56,6 +57,41 @@ func TestIsLinux(t *testing.T) {
 	})
 }
 
+func TestOutputs(t *testing.T) {
+	Convey("Configs should be rendered for wg-quick by default", t, func() {
+		So((&Peer{}).Outputs(), ShouldResemble, []string{OutputWGQuick})
+		So((&Peer{Output: "networkd"}).Outputs(), ShouldResemble, []string{OutputNetworkd})
+		So((&Peer{Output: "wg-quick, networkd"}).Outputs(), ShouldResemble, []string{OutputWGQuick, OutputNetworkd})
+		So((&Peer{OS: OSOpenWrt}).Outputs(), ShouldResemble, []string{OutputOpenWrt})
+		So((&Peer{OS: OSOpenWrt, Output: "wg-quick"}).Outputs(), ShouldResemble, []string{OutputWGQuick})
+		So((&Peer{OS: OSRouterOS}).Outputs(), ShouldResemble, []string{OutputRouterOS})
+		So((&Peer{OS: OSVyOS}).Outputs(), ShouldResemble, []string{OutputVyOS})
+		So((&Peer{OS: OSOPNsense}).Outputs(), ShouldResemble, []string{OutputOPNsense})
+		So((&Peer{OS: OSNixOS}).Outputs(), ShouldResemble, []string{OutputNixOS})
+		So((&Peer{OS: OSiOS}).Outputs(), ShouldResemble, []string{OutputMobileConfig})
+		So((&Peer{OS: OSMacOS}).Outputs(), ShouldResemble, []string{OutputMobileConfig})
+	})
+}
+
+func TestIsPublicKeyOnly(t *testing.T) {
+	Convey("Create Peers with different keys", t, func() {
+		empty := new(Peer)
+
+		publicOnly := new(Peer)
+		publicOnly.PublicKey = "pub"
+
+		both := new(Peer)
+		both.PublicKey = "pub"
+		both.PrivateKey = "pri"
+
+		So(empty.IsPublicKeyOnly(), ShouldBeFalse)
+		So(publicOnly.IsPublicKeyOnly(), ShouldBeTrue)
+		So(both.IsPublicKeyOnly(), ShouldBeFalse)
+		So(empty.Validate(), ShouldNotBeNil)
+		So(publicOnly.Validate(), ShouldBeNil)
+	})
+}
+
 func TestIsBounceServer(t *testing.T) {
 	Convey("Create Peers with different properties", t, func() {
 		empty := Peer{}
@@ -113,8 +149,166 @@ func TestAllowedIPsForPeer(t *testing.T) {
 	})
 }
 
+func TestNextFreeAddress(t *testing.T) {
+	Convey("Allocate addresses within the subnet", t, func() {
+		conf := &Config{Network: Network{Subnet: "192.168.25.0/30"}}
+		address, err := conf.NextFreeAddress()
+		So(err, ShouldBeNil)
+		So(address, ShouldEqual, "192.168.25.1/32")
+
+		conf.Peers = append(conf.Peers, Peer{})
+		conf.Peers[0].Address = address
+		address, err = conf.NextFreeAddress()
+		So(err, ShouldBeNil)
+		So(address, ShouldEqual, "192.168.25.2/32")
+
+		conf.Peers = append(conf.Peers, Peer{})
+		conf.Peers[1].Address = address
+		_, err = conf.NextFreeAddress()
+		So(err, ShouldNotBeNil)
+
+		conf = &Config{Network: Network{Subnet: "fd00::/64"}}
+		address, err = conf.NextFreeAddress()
+		So(err, ShouldBeNil)
+		So(address, ShouldEqual, "fd00::1/128")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	Convey("Validate the example config", t, func() {
+		file, err := ini.LoadSources(LoadOptions, strings.NewReader(example.FileConfExample))
+		So(err, ShouldBeNil)
+		conf := new(Config)
+		So(file.MapTo(conf), ShouldBeNil)
+		So(conf.Validate(), ShouldBeNil)
+
+		Convey("Multiple subnets should be accepted", func() {
+			conf.Peers[1].AllowedIPs = "10.1.1.0/24,10.2.2.0/24"
+			So(conf.Validate(), ShouldBeNil)
+			conf.Peers[1].AllowedIPs = "10.1.1.0/24,10.2.2.0"
+			So(conf.Validate(), ShouldNotBeNil)
+		})
+		Convey("Duplicated IDs should be rejected", func() {
+			conf.Peers[1].ID = conf.Peers[0].ID
+			So(conf.Validate(), ShouldNotBeNil)
+		})
+		Convey("Duplicated addresses should be rejected", func() {
+			conf.Peers[1].Address = conf.Peers[0].Address
+			So(conf.Validate(), ShouldNotBeNil)
+		})
+		Convey("Addresses out of the subnet should be rejected", func() {
+			conf.Peers[1].Address = "10.0.0.1/32"
+			So(conf.Validate(), ShouldNotBeNil)
+		})
+		Convey("Unknown outputs should be rejected", func() {
+			conf.Peers[1].Output = "wg-quick,ifupdown"
+			So(conf.Validate(), ShouldNotBeNil)
+		})
+		Convey("Interfaces of VyOS should be named like wg0", func() {
+			conf.Peers[1].OS = OSVyOS
+			So(conf.Validate(), ShouldNotBeNil)
+			conf.Peers[1].InterfaceName = "wg-example"
+			So(conf.Validate(), ShouldNotBeNil)
+			conf.Peers[1].InterfaceName = "wg1"
+			So(conf.Validate(), ShouldBeNil)
+		})
+	})
+}
+
 func TestIsIPInSubnet(t *testing.T) {
 	Convey("", t, func() {
 		So(isIPInSubnets("10.1.1.0/32", []string{"10.1.1.0/24"}), ShouldBeTrue)
 	})
 }
+
+func TestExpiry(t *testing.T) {
+	Convey("Create Peers with different expiries", t, func() {
+		now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.Local)
+		never := Peer{ID: "never"}
+		date := Peer{ID: "date", ExpiresAt: "2020-06-01"}
+		dateTime := Peer{ID: "dateTime", ExpiresAt: "2020-06-01 13:00"}
+		rfc3339 := Peer{ID: "rfc3339", ExpiresAt: "2020-06-05T00:00:00Z"}
+		invalid := Peer{ID: "invalid", ExpiresAt: "tomorrow"}
+		invalid.PublicKey = "pub"
+
+		Convey("Supported layouts should be parsed", func() {
+			_, ok, err := never.Expiry()
+			So(ok, ShouldBeFalse)
+			So(err, ShouldBeNil)
+
+			expiry, ok, err := date.Expiry()
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+			So(expiry, ShouldEqual, time.Date(2020, 6, 1, 0, 0, 0, 0, time.Local))
+
+			_, _, err = invalid.Expiry()
+			So(err, ShouldNotBeNil)
+			So(invalid.Validate(), ShouldNotBeNil)
+		})
+		Convey("Expired peers should be detected", func() {
+			for peer, expected := range map[*Peer]bool{&never: false, &date: true, &dateTime: false, &rfc3339: false} {
+				expired, err := peer.IsExpiredAt(now)
+				So(err, ShouldBeNil)
+				So(expired, ShouldEqual, expected)
+			}
+		})
+		Convey("Peers expiring soon should be listed", func() {
+			conf := &Config{Peers: []Peer{never, date, dateTime, rfc3339}}
+			active, err := conf.ActivePeers(now)
+			So(err, ShouldBeNil)
+			So(active, ShouldHaveLength, 3)
+
+			expiring, err := conf.PeersExpiringWithin(now, 24*time.Hour)
+			So(err, ShouldBeNil)
+			So(expiring, ShouldHaveLength, 2)
+			So(expiring[0].ID, ShouldEqual, "date")
+			So(expiring[1].ID, ShouldEqual, "dateTime")
+
+			conf.Peers = append(conf.Peers, invalid)
+			_, err = conf.ActivePeers(now)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestIsActiveAt(t *testing.T) {
+	Convey("Create Peers disabled or expired", t, func() {
+		now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.Local)
+		active := Peer{ID: "active"}
+		disabled := Peer{ID: "disabled", Disabled: true}
+		expired := Peer{ID: "expired", ExpiresAt: "2020-06-01"}
+
+		for peer, expected := range map[*Peer]bool{&active: true, &disabled: false, &expired: false} {
+			ok, err := peer.IsActiveAt(now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldEqual, expected)
+		}
+		conf := &Config{Peers: []Peer{active, disabled, expired}}
+		peers, err := conf.ActivePeers(now)
+		So(err, ShouldBeNil)
+		So(peers, ShouldResemble, []Peer{active})
+	})
+}
+
+func TestPresharedKeyWith(t *testing.T) {
+	Convey("Create Peers with PresharedKeys", t, func() {
+		server := Peer{ID: "server"}
+		server.Endpoint = "server.example.com:51820"
+		server.PublicInterface = "eth0"
+		server.PresharedKey = "psk-of-server"
+		other := server
+		other.ID = "other"
+		client := Peer{ID: "client"}
+		client.PresharedKey = "psk-of-client"
+
+		Convey("The PresharedKey of the client should be used", func() {
+			So(server.PresharedKeyWith(&client), ShouldEqual, "psk-of-client")
+			So(client.PresharedKeyWith(&server), ShouldEqual, "psk-of-client")
+		})
+		Convey("Bounce servers should only use the same PresharedKey", func() {
+			So(server.PresharedKeyWith(&other), ShouldEqual, "psk-of-server")
+			other.PresharedKey = "psk-of-other"
+			So(server.PresharedKeyWith(&other), ShouldBeEmpty)
+		})
+	})
+}
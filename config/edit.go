@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reSection = regexp.MustCompile(`^\s*\[(.+)\]\s*$`)
+	reKey     = regexp.MustCompile(`^\s*([^#;=\s]+)\s*=\s*(.*?)\s*$`)
+)
+
+// FormatPeer returns the Peer section of given peer in the network description format.
+func FormatPeer(p *Peer) string {
+	var buf bytes.Buffer
+	buf.WriteString("[Peer]\n")
+	field := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s = %s\n", key, value)
+		}
+	}
+	number := func(key string, value int) {
+		if value != 0 {
+			field(key, strconv.Itoa(value))
+		}
+	}
+	field("ID", p.ID)
+	field("Address", p.Address)
+	field("LocalSubnets", p.LocalSubnets)
+	field("DNS", p.DNS)
+	field("PrivateKey", p.PrivateKey)
+	field("PublicKey", p.PublicKey)
+	field("PresharedKey", p.PresharedKey)
+	number("ListenPort", p.ListenPort)
+	field("Endpoint", p.Endpoint)
+	field("AllowedIPs", p.AllowedIPs)
+	field("PublicInterface", p.PublicInterface)
+	field("OS", p.OS)
+	number("PersistentKeepalive", p.PersistentKeepalive)
+	field("ExpiresAt", p.ExpiresAt)
+	if p.Disabled {
+		field("Disabled", "true")
+	}
+	field("Output", p.Output)
+	field("InterfaceName", p.InterfaceName)
+	field("TrustedSSIDs", p.TrustedSSIDs)
+	return buf.String()
+}
+
+// FormatNetwork returns the network description of conf.
+func FormatNetwork(conf *Config) []byte {
+	src := []byte(fmt.Sprintf("[Network]\nID = %s\nSubnet = %s\n", conf.Network.ID, conf.Network.Subnet))
+	for i := range conf.Peers {
+		src = AppendPeer(src, &conf.Peers[i])
+	}
+	return src
+}
+
+// AppendPeer returns the network description src with the Peer section of given peer appended.
+//
+// The existing content of src is kept untouched.
+func AppendPeer(src []byte, p *Peer) []byte {
+	dst := bytes.TrimRight(src, "\n")
+	dst = append(dst[:len(dst):len(dst)], "\n\n\n"...)
+	return append(dst, FormatPeer(p)...)
+}
+
+// RemovePeer returns the network description src without the Peer section of given ID.
+//
+// The comments right above the section are considered as part of it and removed as well,
+// while the comments right above the next section are kept.
+func RemovePeer(src []byte, id string) ([]byte, error) {
+	lines := strings.SplitAfter(string(src), "\n")
+	start, end := -1, len(lines)
+	for i := 0; i < len(lines); i++ {
+		m := reSection.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		if start >= 0 {
+			end = i
+			break
+		}
+		if strings.EqualFold(m[1], "Peer") && sectionID(lines[i+1:]) == id {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("peer(%s) not found", id)
+	}
+
+	isLast := end == len(lines)
+	// Keep the comments describing the next section.
+	if !isLast {
+		for end > start && isComment(lines[end-1]) {
+			end--
+		}
+	}
+	for end > start && isBlank(lines[end-1]) {
+		end--
+	}
+	// Remove the comments describing this section.
+	for start > 0 && isComment(lines[start-1]) {
+		start--
+	}
+
+	remaining := append(lines[:start:start], lines[end:]...)
+	dst := strings.Join(remaining, "")
+	if isLast {
+		dst = strings.TrimRight(dst, "\n") + "\n"
+	}
+	return []byte(dst), nil
+}
+
+// sectionID returns the ID within the section starting from given lines.
+func sectionID(lines []string) string {
+	for _, line := range lines {
+		if reSection.MatchString(line) {
+			break
+		}
+		m := reKey.FindStringSubmatch(line)
+		if m != nil && strings.EqualFold(m[1], "ID") {
+			return strings.Trim(m[2], `"`)
+		}
+	}
+	return ""
+}
+
+func isComment(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";")
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
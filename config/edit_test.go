@@ -0,0 +1,92 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/tevino/wg-make/example"
+	"gopkg.in/ini.v1"
+)
+
+func loadConfig(src []byte) (*Config, error) {
+	file, err := ini.LoadSources(LoadOptions, src)
+	if err != nil {
+		return nil, err
+	}
+	conf := new(Config)
+	return conf, file.MapTo(conf)
+}
+
+func TestAppendPeer(t *testing.T) {
+	Convey("Append a peer to the example", t, func() {
+		bob := Peer{ID: "Bob", LocalSubnets: "10.2.2.0/24"}
+		bob.Address = "192.168.25.2/32"
+		bob.PrivateKey = "private-key-of-bob"
+		bob.PublicKey = "public-key-of-bob"
+		bob.PersistentKeepalive = 25
+		bob.Output = OutputNetworkd
+		bob.InterfaceName = "wg1"
+
+		src := AppendPeer([]byte(example.FileConfExample), &bob)
+		So(string(src), ShouldStartWith, example.FileConfExample)
+
+		conf, err := loadConfig(src)
+		So(err, ShouldBeNil)
+		So(conf.Peers, ShouldHaveLength, 4)
+		So(conf.Peers[3], ShouldResemble, bob)
+	})
+}
+
+func TestFormatNetwork(t *testing.T) {
+	Convey("Format the example network", t, func() {
+		conf, err := loadConfig([]byte(example.FileConfExample))
+		So(err, ShouldBeNil)
+
+		formatted, err := loadConfig(FormatNetwork(conf))
+		So(err, ShouldBeNil)
+		So(formatted, ShouldResemble, conf)
+	})
+}
+
+func TestRemovePeer(t *testing.T) {
+	Convey("Remove peers from the example", t, func() {
+		src := []byte(example.FileConfExample)
+
+		Convey("Removing a peer in the middle", func() {
+			dst, err := RemovePeer(src, "Tento")
+			So(err, ShouldBeNil)
+			So(string(dst), ShouldNotContainSubstring, "Tento")
+			So(string(dst), ShouldNotContainSubstring, "# This is a client peer.")
+			So(string(dst), ShouldContainSubstring, "# The peer acting as a server, relaying traffic for client peers.\n[Peer]\nID = Pata")
+			So(string(dst), ShouldContainSubstring, "# NOTE: Customizing number of the routing table is not supported for the moment.\n")
+
+			conf, err := loadConfig(dst)
+			So(err, ShouldBeNil)
+			So(conf.Peers, ShouldHaveLength, 2)
+			So(conf.Peers[0].ID, ShouldEqual, "Pata")
+			So(conf.Peers[1].ID, ShouldEqual, "Agu")
+		})
+		Convey("Removing the last peer", func() {
+			dst, err := RemovePeer(src, "Agu")
+			So(err, ShouldBeNil)
+			So(string(dst), ShouldNotContainSubstring, "Agu")
+			So(string(dst), ShouldEndWith, "OS = Linux\n")
+
+			conf, err := loadConfig(dst)
+			So(err, ShouldBeNil)
+			So(conf.Peers, ShouldHaveLength, 2)
+		})
+		Convey("Removing an appended peer restores the original", func() {
+			bob := Peer{ID: "Bob"}
+			dst, err := RemovePeer(AppendPeer(src, &bob), "Bob")
+			So(err, ShouldBeNil)
+			So(string(dst), ShouldEqual, example.FileConfExample)
+		})
+		Convey("Removing an unknown peer", func() {
+			_, err := RemovePeer(src, "Nobody")
+			So(err, ShouldNotBeNil)
+			So(strings.Count(string(src), "\n[Peer]\n"), ShouldEqual, 3)
+		})
+	})
+}
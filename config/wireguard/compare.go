@@ -0,0 +1,187 @@
+package wireguard
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// CompareOptions controls how configs are compared.
+type CompareOptions struct {
+	// LookupHost resolves hostnames of endpoints, as `wg showconf` prints resolved addresses.
+	// Endpoints with hostnames never match addresses if it's nil.
+	LookupHost func(host string) ([]string, error)
+	// IgnorePrivateKey skips comparing private keys, e.g. for peers keeping them on the devices.
+	IgnorePrivateKey bool
+}
+
+// Compare returns the semantic differences between the expected config and the actual one, ordering and comments are ignored.
+//
+// The actual config could be the output of `wg showconf`, in which case settings only known to wg-quick,
+// i.e. Address, DNS, MTU, Table and hooks, are not compared as they are missing, neither are endpoints learned from
+// peers without one in the expected config. Peers are matched by public keys.
+func Compare(expected, actual *Config, opts CompareOptions) []string {
+	diffs := []string{}
+	differ := func(field string, e, a interface{}) {
+		diffs = append(diffs, fmt.Sprintf("%s: expected %v, actual %v", field, orNone(e), orNone(a)))
+	}
+
+	ei, ai := &expected.Interface, &actual.Interface
+	if !opts.IgnorePrivateKey && ei.PrivateKey != ai.PrivateKey {
+		diffs = append(diffs, "Interface PrivateKey differs")
+	}
+	// Ports are picked randomly if not set, which shows up in `wg showconf`.
+	if ei.ListenPort != ai.ListenPort && (ei.ListenPort != 0 || ai.Address != "") {
+		differ("Interface ListenPort", ei.ListenPort, ai.ListenPort)
+	}
+	if ei.FwMark != ai.FwMark {
+		differ("Interface FwMark", ei.FwMark, ai.FwMark)
+	}
+	isWGQuick := ai.Address != ""
+	if isWGQuick {
+		if e, a := normalizeList(ei.Address, normalizeAddress), normalizeList(ai.Address, normalizeAddress); e != a {
+			differ("Interface Address", e, a)
+		}
+		if e, a := normalizeList(ei.DNS, nil), normalizeList(ai.DNS, nil); e != a {
+			differ("Interface DNS", e, a)
+		}
+		if ei.MTU != ai.MTU {
+			differ("Interface MTU", ei.MTU, ai.MTU)
+		}
+		if ei.Table != ai.Table {
+			differ("Interface Table", ei.Table, ai.Table)
+		}
+		for _, hooks := range []struct {
+			key      string
+			expected []string
+			actual   []string
+		}{
+			{"PreUp", ei.PreUps, ai.PreUps},
+			{"PostUp", ei.PostUps, ai.PostUps},
+			{"PreDown", ei.PreDowns, ai.PreDowns},
+			{"PostDown", ei.PostDowns, ai.PostDowns},
+		} {
+			if strings.Join(hooks.expected, "\n") != strings.Join(hooks.actual, "\n") {
+				differ("Interface "+hooks.key, strings.Join(hooks.expected, "; "), strings.Join(hooks.actual, "; "))
+			}
+		}
+	}
+
+	actualPeers := map[string]*Peer{}
+	for i := range actual.Peers {
+		actualPeers[actual.Peers[i].PublicKey] = &actual.Peers[i]
+	}
+	for i := range expected.Peers {
+		e := &expected.Peers[i]
+		name := peerName(e)
+		a, ok := actualPeers[e.PublicKey]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s is missing", name))
+			continue
+		}
+		delete(actualPeers, e.PublicKey)
+		if e.PresharedKey != a.PresharedKey {
+			diffs = append(diffs, fmt.Sprintf("%s PresharedKey differs", name))
+		}
+		// Endpoints of roaming peers are learned from their packets, which shows up in `wg showconf`.
+		learned := e.Endpoint == "" && !isWGQuick
+		if !learned && !endpointsMatch(e.Endpoint, a.Endpoint, opts.LookupHost) {
+			differ(name+" Endpoint", e.Endpoint, a.Endpoint)
+		}
+		if ea, aa := normalizeList(e.AllowedIPs, normalizeSubnet), normalizeList(a.AllowedIPs, normalizeSubnet); ea != aa {
+			differ(name+" AllowedIPs", ea, aa)
+		}
+		if e.PersistentKeepalive != a.PersistentKeepalive {
+			differ(name+" PersistentKeepalive", e.PersistentKeepalive, a.PersistentKeepalive)
+		}
+	}
+	unexpected := []string{}
+	for _, a := range actualPeers {
+		unexpected = append(unexpected, fmt.Sprintf("%s is unexpected", peerName(a)))
+	}
+	sort.Strings(unexpected)
+	return append(diffs, unexpected...)
+}
+
+func peerName(p *Peer) string {
+	if p.ID != "" {
+		return fmt.Sprintf("Peer(%s)", p.ID)
+	}
+	return fmt.Sprintf("Peer(%s)", p.PublicKey)
+}
+
+func orNone(v interface{}) interface{} {
+	if v == "" || v == 0 {
+		return "none"
+	}
+	return v
+}
+
+// normalizeList returns the sorted items of a comma-separated list, each normalized by normalize if it's not nil.
+func normalizeList(list string, normalize func(item string) string) string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if normalize != nil {
+			item = normalize(item)
+		}
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// normalizeAddress returns the canonical form of an address with its prefix length, host bits are kept.
+func normalizeAddress(item string) string {
+	if ip, subnet, err := net.ParseCIDR(item); err == nil {
+		ones, _ := subnet.Mask.Size()
+		return fmt.Sprintf("%s/%d", ip, ones)
+	}
+	return item
+}
+
+// normalizeSubnet returns the canonical form of a subnet with host bits masked, as `wg showconf` prints AllowedIPs.
+func normalizeSubnet(item string) string {
+	if _, subnet, err := net.ParseCIDR(item); err == nil {
+		return subnet.String()
+	}
+	return item
+}
+
+// endpointsMatch returns true if the actual endpoint is the expected one, or the address it's resolved to.
+func endpointsMatch(expected, actual string, lookupHost func(string) ([]string, error)) bool {
+	if expected == actual {
+		return true
+	}
+	eHost, ePort, errE := net.SplitHostPort(expected)
+	aHost, aPort, errA := net.SplitHostPort(actual)
+	if errE != nil || errA != nil || ePort != aPort {
+		return false
+	}
+	eIP, aIP := net.ParseIP(eHost), net.ParseIP(aHost)
+	switch {
+	case eIP != nil && aIP != nil:
+		return eIP.Equal(aIP)
+	case eIP == nil && aIP == nil:
+		return strings.EqualFold(eHost, aHost)
+	case eIP != nil:
+		return false
+	}
+	if lookupHost == nil {
+		return false
+	}
+	addrs, err := lookupHost(eHost)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if net.ParseIP(addr).Equal(aIP) {
+			return true
+		}
+	}
+	return false
+}
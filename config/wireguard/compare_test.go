@@ -0,0 +1,116 @@
+package wireguard
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCompare(t *testing.T) {
+	Convey("Compare configs", t, func() {
+		expected := &Config{
+			Interface: Interface{
+				ID:         "Pata",
+				PrivateKey: "priKey",
+				Address:    "192.0.2.1/32",
+				ListenPort: 51820,
+				PostUps:    []string{"/pos/up %i"},
+			},
+			Peers: []Peer{
+				{ID: "Tento", PublicKey: "pubKey1", AllowedIPs: "192.0.2.2/32,10.1.0.1/24"},
+				{ID: "Agu", PublicKey: "pubKey2", Endpoint: "agu.example.com:51820", AllowedIPs: "192.0.2.3/32", PersistentKeepalive: 25},
+			},
+		}
+		lookupHost := func(host string) ([]string, error) {
+			if host == "agu.example.com" {
+				return []string{"198.51.100.3"}, nil
+			}
+			return nil, errors.New("no such host")
+		}
+		opts := CompareOptions{LookupHost: lookupHost}
+
+		Convey("Ordering, comments and formatting should be ignored", func() {
+			f, err := Parse([]byte(`[Peer]
+PublicKey = pubKey2
+Endpoint = agu.example.com:51820
+AllowedIPs = 192.0.2.3/32
+PersistentKeepalive = 25
+
+# Tento
+[Peer]
+PublicKey = pubKey1
+AllowedIPs = 10.1.0.0/24, 192.0.2.2/32
+
+[Interface]
+PostUp = /pos/up %i
+ListenPort = 51820
+Address = 192.0.2.1/32
+PrivateKey = priKey
+`))
+			So(err, ShouldBeNil)
+			actual, _, err := f.Config()
+			So(err, ShouldBeNil)
+			So(Compare(expected, actual, opts), ShouldBeEmpty)
+		})
+
+		Convey("Output of wg showconf should be compared without wg-quick settings and learned endpoints", func() {
+			f, err := Parse([]byte(`[Interface]
+ListenPort = 51820
+PrivateKey = priKey
+
+[Peer]
+PublicKey = pubKey1
+Endpoint = 203.0.113.7:40123
+AllowedIPs = 192.0.2.2/32, 10.1.0.0/24
+
+[Peer]
+PublicKey = pubKey2
+Endpoint = 198.51.100.3:51820
+AllowedIPs = 192.0.2.3/32
+PersistentKeepalive = 25
+`))
+			So(err, ShouldBeNil)
+			actual, _, err := f.Config()
+			So(err, ShouldBeNil)
+			So(Compare(expected, actual, opts), ShouldBeEmpty)
+
+			Convey("Resolved endpoints should not match without LookupHost", func() {
+				So(Compare(expected, actual, CompareOptions{}), ShouldResemble, []string{
+					"Peer(Agu) Endpoint: expected agu.example.com:51820, actual 198.51.100.3:51820",
+				})
+			})
+		})
+
+		Convey("Host bits should be masked in AllowedIPs but kept in Address", func() {
+			So(normalizeList("10.1.0.1/24, 2001:db8::1/64", normalizeSubnet), ShouldEqual, "10.1.0.0/24,2001:db8::/64")
+			So(normalizeList("192.0.2.1/24", normalizeAddress), ShouldEqual, "192.0.2.1/24")
+
+			actual := *expected
+			actual.Interface.Address = "192.0.2.1/24"
+			So(Compare(expected, &actual, opts), ShouldResemble, []string{
+				"Interface Address: expected 192.0.2.1/32, actual 192.0.2.1/24",
+			})
+		})
+		Convey("Every difference should be reported", func() {
+			actual := &Config{
+				Interface: Interface{PrivateKey: "other", Address: "192.0.2.1/32"},
+				Peers: []Peer{
+					{PublicKey: "pubKey1", PresharedKey: "psk", Endpoint: "203.0.113.7:40123", AllowedIPs: "192.0.2.2/32"},
+					{PublicKey: "pubKey3", AllowedIPs: "192.0.2.4/32"},
+				},
+			}
+			So(Compare(expected, actual, opts), ShouldResemble, []string{
+				"Interface PrivateKey differs",
+				"Interface ListenPort: expected 51820, actual none",
+				"Interface PostUp: expected /pos/up %i, actual none",
+				"Peer(Tento) PresharedKey differs",
+				"Peer(Tento) Endpoint: expected none, actual 203.0.113.7:40123",
+				"Peer(Tento) AllowedIPs: expected 10.1.0.0/24,192.0.2.2/32, actual 192.0.2.2/32",
+				"Peer(Agu) is missing",
+				"Peer(pubKey3) is unexpected",
+			})
+			So(Compare(expected, actual, CompareOptions{IgnorePrivateKey: true}), ShouldNotContain, "Interface PrivateKey differs")
+		})
+	})
+}
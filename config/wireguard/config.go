@@ -10,21 +10,29 @@ type Config struct {
 
 // Interface reflects the Interface section in a wireguard configuration file.
 type Interface struct {
+	// ID identifies the interface in comments, it's not part of the WireGuard configuration.
+	ID         string   `ini:"-"`
 	PrivateKey string   `ini:"PrivateKey,omitempty"`
 	Address    string   `ini:"Address,omitempty"`
 	ListenPort int      `ini:"ListenPort,omitempty"`
+	FwMark     string   `ini:"FwMark,omitempty"`
 	DNS        string   `ini:"DNS,omitempty"`
 	MTU        int      `ini:"MTU,omitempty"`
+	Table      string   `ini:"Table,omitempty"`
 	PreUps     []string `ini:"PreUp,omitempty,allowshadow"`
 	PreDowns   []string `ini:"PreDown,omitempty,allowshadow"`
 	PostUps    []string `ini:"PostUp,omitempty,allowshadow"`
 	PostDowns  []string `ini:"PostDown,omitempty,allowshadow"`
+	SaveConfig bool     `ini:"SaveConfig,omitempty"`
 }
 
 // Peer reflects the Peer section in a wireguard configuration file.
 type Peer struct {
+	// ID identifies the peer in comments, it's not part of the WireGuard configuration.
+	ID                  string `ini:"-"`
 	Endpoint            string `ini:"Endpoint,omitempty"`
 	PublicKey           string `ini:"PublicKey,omitempty"`
+	PresharedKey        string `ini:"PresharedKey,omitempty"`
 	AllowedIPs          string `ini:"AllowedIPs,omitempty"`
 	PersistentKeepalive int    `ini:"PersistentKeepalive,omitempty"`
 }
@@ -56,6 +56,7 @@ func TestMapping(t *testing.T) {
 				So(sIF.PrivateKey, ShouldEqual, "priKey")
 				So(sIF.DNS, ShouldEqual, "1.1.1.1,8.8.8.8")
 				So(sIF.MTU, ShouldEqual, 1500)
+				So(sIF.Table, ShouldEqual, "12345")
 				So(sIF.PostUps, ShouldResemble, []string{"/pos/up 1 %i", "/pos/up 2 %i"})
 				So(sIF.PostDowns, ShouldResemble, []string{"/pos/down 1 %i", "/pos/down 2 %i"})
 				So(sIF.PreUps, ShouldResemble, []string{"/pre/up 1 %i", "/pre/up 2 %i"})
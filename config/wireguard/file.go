@@ -0,0 +1,404 @@
+package wireguard
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// LineKind is the kind of a line in a wg-quick configuration file.
+type LineKind int
+
+// All kinds of lines.
+const (
+	LineBlank LineKind = iota
+	LineComment
+	LineSection
+	LineKey
+)
+
+// Section names of a wg-quick configuration file.
+const (
+	SectionInterface = "Interface"
+	SectionPeer      = "Peer"
+)
+
+// Line is a line of a wg-quick configuration file.
+type Line struct {
+	Kind LineKind
+	// Raw is the line as it is in the file, including the line ending.
+	Raw string
+	// Section is the name of the section the line belongs to, or the one it starts.
+	Section string
+	// Key and Value are set for LineKey, inline comments are stripped from Value.
+	Key   string
+	Value string
+}
+
+// File is a wg-quick configuration file, comments, ordering and formatting are preserved.
+//
+// SetInterface, SetPeer, AddPeer and RemovePeer edit the file in place, lines unrelated to an edit are left untouched.
+type File struct {
+	Lines []Line
+}
+
+// UnknownKey is a key not supported by wg-quick.
+type UnknownKey struct {
+	// Line number starting from 1.
+	Line    int
+	Section string
+	Key     string
+}
+
+func (k UnknownKey) String() string {
+	return fmt.Sprintf("line %d: unknown key %s in section %s", k.Line, k.Key, k.Section)
+}
+
+// LoadFile reads and parses the wg-quick configuration file at filePath.
+func LoadFile(filePath string) (*File, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading config(%s): %w", filePath, err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config(%s): %w", filePath, err)
+	}
+	return f, nil
+}
+
+// Parse parses a wg-quick configuration file, Bytes of the result is always identical to data.
+func Parse(data []byte) (*File, error) {
+	f := &File{}
+	section := ""
+	for i, raw := range strings.SplitAfter(string(data), "\n") {
+		if raw == "" {
+			continue
+		}
+		line := Line{Raw: raw}
+		// Inline comments are ignored the same way as wg-quick does.
+		text := raw
+		if c := strings.IndexByte(text, '#'); c >= 0 {
+			text = text[:c]
+		}
+		text = strings.TrimSpace(text)
+		switch {
+		case text == "" && strings.TrimSpace(raw) == "":
+			line.Kind = LineBlank
+		case text == "":
+			line.Kind = LineComment
+		case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+			line.Kind = LineSection
+			section = canonicalSection(strings.TrimSpace(text[1 : len(text)-1]))
+			if section != SectionInterface && section != SectionPeer {
+				return nil, fmt.Errorf("line %d: unknown section [%s]", i+1, section)
+			}
+		default:
+			eq := strings.IndexByte(text, '=')
+			if eq < 0 {
+				return nil, fmt.Errorf("line %d: expecting Key = Value: %s", i+1, text)
+			}
+			if section == "" {
+				return nil, fmt.Errorf("line %d: key outside of sections: %s", i+1, text)
+			}
+			line.Kind = LineKey
+			line.Key = strings.TrimSpace(text[:eq])
+			line.Value = strings.TrimSpace(text[eq+1:])
+		}
+		line.Section = section
+		f.Lines = append(f.Lines, line)
+	}
+	return f, nil
+}
+
+// Bytes returns the content of the file.
+func (f *File) Bytes() []byte {
+	var buf strings.Builder
+	for _, l := range f.Lines {
+		buf.WriteString(l.Raw)
+	}
+	return []byte(buf.String())
+}
+
+// Config returns the configuration within the file along with the keys not supported by wg-quick.
+//
+// Keys are case-insensitive, repeated Address, DNS and AllowedIPs are joined by commas.
+// The IDs of the interface and peers are read from comments like "# ID = Tento" within their sections.
+func (f *File) Config() (*Config, []UnknownKey, error) {
+	conf := &Config{}
+	unknown := []UnknownKey{}
+	var peer *Peer
+	for i, l := range f.Lines {
+		switch l.Kind {
+		case LineSection:
+			peer = nil
+			if l.Section == SectionPeer {
+				conf.Peers = append(conf.Peers, Peer{})
+				peer = &conf.Peers[len(conf.Peers)-1]
+			}
+		case LineComment:
+			id, ok := idInComment(l.Raw)
+			if !ok {
+				continue
+			}
+			if peer != nil {
+				peer.ID = id
+			} else if l.Section == SectionInterface {
+				conf.Interface.ID = id
+			}
+		case LineKey:
+			var known bool
+			var err error
+			if peer != nil {
+				known, err = peer.set(l.Key, l.Value)
+			} else {
+				known, err = conf.Interface.set(l.Key, l.Value)
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			if !known {
+				unknown = append(unknown, UnknownKey{Line: i + 1, Section: l.Section, Key: l.Key})
+			}
+		}
+	}
+	return conf, unknown, nil
+}
+
+// SetInterface replaces the lines of key in the Interface section with a line for each of values.
+//
+// The first line of key is replaced in place, new keys are added after the last key of the section
+// and the key is removed if values are empty.
+func (f *File) SetInterface(key string, values ...string) error {
+	start, end, ok := f.interfaceSection()
+	if !ok {
+		return fmt.Errorf("no [%s] section", SectionInterface)
+	}
+	f.set(start, end, key, values)
+	return nil
+}
+
+// SetPeer is the same as SetInterface, but for the Peer section with publicKey.
+func (f *File) SetPeer(publicKey, key string, values ...string) error {
+	start, end, ok := f.peerSection(publicKey)
+	if !ok {
+		return fmt.Errorf("no [%s] section with PublicKey %s", SectionPeer, publicKey)
+	}
+	f.set(start, end, key, values)
+	return nil
+}
+
+// AddPeer appends a Peer section for p to the end of the file in the same format as Format.
+func (f *File) AddPeer(p *Peer) error {
+	if _, _, ok := f.peerSection(p.PublicKey); ok {
+		return fmt.Errorf("duplicate [%s] section with PublicKey %s", SectionPeer, p.PublicKey)
+	}
+	var buf strings.Builder
+	if len(f.Lines) > 0 {
+		buf.WriteString("\n")
+	}
+	writePeer(&buf, p)
+	added, err := Parse([]byte(buf.String()))
+	if err != nil {
+		return fmt.Errorf("parsing peer(%s): %w", p.PublicKey, err)
+	}
+	f.insert(len(f.Lines), added.Lines...)
+	return nil
+}
+
+// RemovePeer removes the Peer section with publicKey along with the comments right above it,
+// false is returned if there is no such section.
+func (f *File) RemovePeer(publicKey string) bool {
+	start, end, ok := f.peerSection(publicKey)
+	if !ok {
+		return false
+	}
+	for start > 0 && f.Lines[start-1].Kind == LineComment {
+		start--
+	}
+	f.Lines = append(f.Lines[:start], f.Lines[end:]...)
+	return true
+}
+
+// sections returns the ranges [start, end) of lines of every section, each starting with its header.
+func (f *File) sections() [][2]int {
+	ranges := [][2]int{}
+	for i, l := range f.Lines {
+		if l.Kind != LineSection {
+			continue
+		}
+		if n := len(ranges); n > 0 {
+			ranges[n-1][1] = i
+		}
+		ranges = append(ranges, [2]int{i, len(f.Lines)})
+	}
+	return ranges
+}
+
+func (f *File) interfaceSection() (start, end int, ok bool) {
+	for _, r := range f.sections() {
+		if f.Lines[r[0]].Section == SectionInterface {
+			return r[0], r[1], true
+		}
+	}
+	return 0, 0, false
+}
+
+func (f *File) peerSection(publicKey string) (start, end int, ok bool) {
+	for _, r := range f.sections() {
+		if f.Lines[r[0]].Section != SectionPeer {
+			continue
+		}
+		for _, l := range f.Lines[r[0]:r[1]] {
+			if l.Kind == LineKey && strings.EqualFold(l.Key, "PublicKey") && l.Value == publicKey {
+				return r[0], r[1], true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// set replaces the lines of key within [start, end) with a line for each of values.
+func (f *File) set(start, end int, key string, values []string) {
+	section := f.Lines[start].Section
+	at := -1
+	for i := end - 1; i >= start; i-- {
+		l := f.Lines[i]
+		if l.Kind != LineKey || !strings.EqualFold(l.Key, key) {
+			continue
+		}
+		// The spelling of the existing key is kept.
+		at, key = i, l.Key
+		f.Lines = append(f.Lines[:i], f.Lines[i+1:]...)
+		end--
+	}
+	if at < 0 {
+		at = start + 1
+		for i := start + 1; i < end; i++ {
+			if f.Lines[i].Kind == LineKey {
+				at = i + 1
+			}
+		}
+	}
+	lines := make([]Line, 0, len(values))
+	for _, v := range values {
+		lines = append(lines, Line{
+			Kind:    LineKey,
+			Raw:     fmt.Sprintf("%s = %s\n", key, v),
+			Section: section,
+			Key:     key,
+			Value:   v,
+		})
+	}
+	f.insert(at, lines...)
+}
+
+// insert inserts lines before the i-th line, a line ending is added to the previous line if missing.
+func (f *File) insert(i int, lines ...Line) {
+	if len(lines) == 0 {
+		return
+	}
+	if i > 0 && !strings.HasSuffix(f.Lines[i-1].Raw, "\n") {
+		f.Lines[i-1].Raw += "\n"
+	}
+	f.Lines = append(f.Lines[:i], append(lines, f.Lines[i:]...)...)
+}
+
+func canonicalSection(name string) string {
+	for _, s := range []string{SectionInterface, SectionPeer} {
+		if strings.EqualFold(name, s) {
+			return s
+		}
+	}
+	return name
+}
+
+// idInComment returns the ID in comments like "# ID = Tento".
+func idInComment(raw string) (string, bool) {
+	comment := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), "#"))
+	eq := strings.IndexByte(comment, '=')
+	if eq < 0 || !strings.EqualFold(strings.TrimSpace(comment[:eq]), "ID") {
+		return "", false
+	}
+	id := strings.TrimSpace(comment[eq+1:])
+	return id, id != ""
+}
+
+func (p *Interface) set(key, value string) (known bool, err error) {
+	switch strings.ToLower(key) {
+	case "privatekey":
+		p.PrivateKey = value
+	case "address":
+		p.Address = joinList(p.Address, value)
+	case "listenport":
+		p.ListenPort, err = parseInt(key, value)
+	case "fwmark":
+		p.FwMark = value
+	case "dns":
+		p.DNS = joinList(p.DNS, value)
+	case "mtu":
+		p.MTU, err = parseInt(key, value)
+	case "table":
+		p.Table = value
+	case "preup":
+		p.PreUps = append(p.PreUps, value)
+	case "predown":
+		p.PreDowns = append(p.PreDowns, value)
+	case "postup":
+		p.PostUps = append(p.PostUps, value)
+	case "postdown":
+		p.PostDowns = append(p.PostDowns, value)
+	case "saveconfig":
+		p.SaveConfig, err = strconv.ParseBool(value)
+		if err != nil {
+			err = fmt.Errorf("parsing %s: %w", key, err)
+		}
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+func (p *Peer) set(key, value string) (known bool, err error) {
+	switch strings.ToLower(key) {
+	case "publickey":
+		p.PublicKey = value
+	case "presharedkey":
+		p.PresharedKey = value
+	case "endpoint":
+		p.Endpoint = value
+	case "allowedips":
+		p.AllowedIPs = joinList(p.AllowedIPs, value)
+	case "persistentkeepalive":
+		if value != "off" {
+			p.PersistentKeepalive, err = parseInt(key, value)
+		}
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+func parseInt(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// joinList appends comma-separated values to list, spaces after commas are removed.
+func joinList(list, values string) string {
+	for _, v := range strings.Split(values, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if list != "" {
+			list += ","
+		}
+		list += v
+	}
+	return list
+}
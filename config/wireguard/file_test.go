@@ -0,0 +1,147 @@
+package wireguard
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const commentedConf = `# Hub of the office.
+[Interface]
+# ID = Hub
+Address = 192.0.2.1/24
+Address = 2001:db8::1/64
+ListenPort = 51820   # opened in the firewall
+PrivateKey = priKey
+FwMark = 0xca6c
+Table = off
+SaveConfig = true
+PostUp = iptables -A FORWARD -i %i -j ACCEPT
+PostUp = sysctl -w net.ipv4.ip_forward=1
+Jc = 4
+
+  # Laptop
+[peer]
+# ID = Laptop
+publickey = pubKey1
+PresharedKey = psk1
+AllowedIPs = 192.0.2.2/32, 10.0.0.0/24
+AllowedIPs = 2001:db8::2/128
+PersistentKeepalive = off
+`
+
+func TestFile(t *testing.T) {
+	Convey("Parse a config with comments", t, func() {
+		f, err := Parse([]byte(commentedConf))
+		So(err, ShouldBeNil)
+
+		Convey("It should round-trip byte for byte", func() {
+			So(string(f.Bytes()), ShouldEqual, commentedConf)
+
+			crlf := strings.Replace(commentedConf, "\n", "\r\n", -1) + "PublicKey = no-newline"
+			f, err := Parse([]byte(crlf))
+			So(err, ShouldBeNil)
+			So(string(f.Bytes()), ShouldEqual, crlf)
+		})
+		Convey("Lines should be classified in order", func() {
+			So(f.Lines[0].Kind, ShouldEqual, LineComment)
+			So(f.Lines[1].Kind, ShouldEqual, LineSection)
+			So(f.Lines[1].Section, ShouldEqual, SectionInterface)
+			So(f.Lines[5].Key, ShouldEqual, "ListenPort")
+			So(f.Lines[5].Value, ShouldEqual, "51820")
+			So(f.Lines[13].Kind, ShouldEqual, LineBlank)
+			So(f.Lines[14].Kind, ShouldEqual, LineComment)
+			So(f.Lines[15].Section, ShouldEqual, SectionPeer)
+		})
+		Convey("Config should be resolved", func() {
+			conf, unknown, err := f.Config()
+			So(err, ShouldBeNil)
+			So(conf.Interface.ID, ShouldEqual, "Hub")
+			So(conf.Interface.Address, ShouldEqual, "192.0.2.1/24,2001:db8::1/64")
+			So(conf.Interface.ListenPort, ShouldEqual, 51820)
+			So(conf.Interface.FwMark, ShouldEqual, "0xca6c")
+			So(conf.Interface.Table, ShouldEqual, "off")
+			So(conf.Interface.SaveConfig, ShouldBeTrue)
+			So(conf.Interface.PostUps, ShouldHaveLength, 2)
+
+			So(conf.Peers, ShouldHaveLength, 1)
+			p := conf.Peers[0]
+			So(p.ID, ShouldEqual, "Laptop")
+			So(p.PublicKey, ShouldEqual, "pubKey1")
+			So(p.PresharedKey, ShouldEqual, "psk1")
+			So(p.AllowedIPs, ShouldEqual, "192.0.2.2/32,10.0.0.0/24,2001:db8::2/128")
+			So(p.PersistentKeepalive, ShouldEqual, 0)
+
+			So(unknown, ShouldResemble, []UnknownKey{{Line: 13, Section: SectionInterface, Key: "Jc"}})
+		})
+	})
+
+	Convey("Edit a config with comments", t, func() {
+		f, err := Parse([]byte(commentedConf))
+		So(err, ShouldBeNil)
+
+		Convey("Existing keys should be replaced in place", func() {
+			So(f.SetInterface("listenport", "51821"), ShouldBeNil)
+			So(f.SetInterface("PostUp", "iptables -A FORWARD -i %i -j ACCEPT"), ShouldBeNil)
+			So(f.SetPeer("pubKey1", "AllowedIPs", "192.0.2.2/32"), ShouldBeNil)
+			So(string(f.Bytes()), ShouldEqual, strings.NewReplacer(
+				"ListenPort = 51820   # opened in the firewall\n", "ListenPort = 51821\n",
+				"PostUp = sysctl -w net.ipv4.ip_forward=1\n", "",
+				"AllowedIPs = 2001:db8::2/128\n", "",
+				"AllowedIPs = 192.0.2.2/32, 10.0.0.0/24\n", "AllowedIPs = 192.0.2.2/32\n",
+			).Replace(commentedConf))
+		})
+		Convey("Missing keys should be added after the last key of the section", func() {
+			So(f.SetInterface("MTU", "1420"), ShouldBeNil)
+			So(f.SetPeer("pubKey1", "Endpoint", "192.0.2.9:51820"), ShouldBeNil)
+			So(string(f.Bytes()), ShouldEqual, strings.NewReplacer(
+				"Jc = 4\n", "Jc = 4\nMTU = 1420\n",
+				"PersistentKeepalive = off\n", "PersistentKeepalive = off\nEndpoint = 192.0.2.9:51820\n",
+			).Replace(commentedConf))
+		})
+		Convey("Keys without values should be removed", func() {
+			So(f.SetInterface("SaveConfig"), ShouldBeNil)
+			So(string(f.Bytes()), ShouldEqual, strings.Replace(commentedConf, "SaveConfig = true\n", "", 1))
+		})
+		Convey("Peers should be added and removed", func() {
+			So(f.AddPeer(&Peer{ID: "Phone", PublicKey: "pubKey2", AllowedIPs: "192.0.2.3/32"}), ShouldBeNil)
+			So(string(f.Bytes()), ShouldEqual, commentedConf+"\n[Peer]\n# ID = Phone\nPublicKey = pubKey2\nAllowedIPs = 192.0.2.3/32\n")
+			So(f.AddPeer(&Peer{PublicKey: "pubKey2"}), ShouldNotBeNil)
+
+			So(f.RemovePeer("pubKey1"), ShouldBeTrue)
+			So(f.RemovePeer("pubKey1"), ShouldBeFalse)
+			conf, _, err := f.Config()
+			So(err, ShouldBeNil)
+			So(conf.Peers, ShouldHaveLength, 1)
+			So(conf.Peers[0].ID, ShouldEqual, "Phone")
+			So(string(f.Bytes()), ShouldNotContainSubstring, "# Laptop")
+		})
+		Convey("Unknown peers should fail", func() {
+			So(f.SetPeer("pubKey9", "Endpoint", "192.0.2.9:51820"), ShouldNotBeNil)
+		})
+		Convey("A missing line ending should be added before new lines", func() {
+			f, err := Parse([]byte("[Interface]\nPrivateKey = priKey"))
+			So(err, ShouldBeNil)
+			So(f.SetInterface("ListenPort", "51820"), ShouldBeNil)
+			So(string(f.Bytes()), ShouldEqual, "[Interface]\nPrivateKey = priKey\nListenPort = 51820\n")
+		})
+	})
+
+	Convey("Malformed configs should fail", t, func() {
+		for _, src := range []string{
+			"PrivateKey = outside\n",
+			"[Interface]\nnot a key\n",
+			"[Tunnel]\n",
+		} {
+			_, err := Parse([]byte(src))
+			So(err, ShouldNotBeNil)
+		}
+
+		f, err := Parse([]byte("[Interface]\nListenPort = high\n"))
+		So(err, ShouldBeNil)
+		_, _, err = f.Config()
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "line 2")
+	})
+}
@@ -0,0 +1,70 @@
+package wireguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format returns conf in the wg-quick format, empty fields are omitted.
+//
+// IDs are written as comments like "# ID = Tento", so they survive a round trip through File.Config.
+func Format(conf *Config) []byte {
+	var buf strings.Builder
+	buf.WriteString("[" + SectionInterface + "]\n")
+	iface := &conf.Interface
+	writeID(&buf, iface.ID)
+	writeKey(&buf, "PrivateKey", iface.PrivateKey)
+	writeKey(&buf, "Address", iface.Address)
+	writeInt(&buf, "ListenPort", iface.ListenPort)
+	writeKey(&buf, "FwMark", iface.FwMark)
+	writeKey(&buf, "DNS", iface.DNS)
+	writeInt(&buf, "MTU", iface.MTU)
+	writeKey(&buf, "Table", iface.Table)
+	writeKeys(&buf, "PreUp", iface.PreUps)
+	writeKeys(&buf, "PostUp", iface.PostUps)
+	writeKeys(&buf, "PreDown", iface.PreDowns)
+	writeKeys(&buf, "PostDown", iface.PostDowns)
+	if iface.SaveConfig {
+		writeKey(&buf, "SaveConfig", "true")
+	}
+
+	for i := range conf.Peers {
+		buf.WriteString("\n")
+		writePeer(&buf, &conf.Peers[i])
+	}
+	return []byte(buf.String())
+}
+
+func writePeer(buf *strings.Builder, p *Peer) {
+	buf.WriteString("[" + SectionPeer + "]\n")
+	writeID(buf, p.ID)
+	writeKey(buf, "PublicKey", p.PublicKey)
+	writeKey(buf, "PresharedKey", p.PresharedKey)
+	writeKey(buf, "Endpoint", p.Endpoint)
+	writeKey(buf, "AllowedIPs", p.AllowedIPs)
+	writeInt(buf, "PersistentKeepalive", p.PersistentKeepalive)
+}
+
+func writeID(buf *strings.Builder, id string) {
+	if id != "" {
+		fmt.Fprintf(buf, "# ID = %s\n", id)
+	}
+}
+
+func writeKey(buf *strings.Builder, key, value string) {
+	if value != "" {
+		fmt.Fprintf(buf, "%s = %s\n", key, value)
+	}
+}
+
+func writeKeys(buf *strings.Builder, key string, values []string) {
+	for _, v := range values {
+		writeKey(buf, key, v)
+	}
+}
+
+func writeInt(buf *strings.Builder, key string, value int) {
+	if value != 0 {
+		fmt.Fprintf(buf, "%s = %d\n", key, value)
+	}
+}
@@ -0,0 +1,61 @@
+package wireguard
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFormat(t *testing.T) {
+	Convey("Format a config", t, func() {
+		conf := &Config{
+			Interface: Interface{
+				ID:         "Hub",
+				PrivateKey: "priKey",
+				Address:    "192.0.2.1/24",
+				ListenPort: 51820,
+				Table:      "off",
+				PostUps:    []string{"/pos/up 1 %i", "/pos/up 2 %i"},
+				SaveConfig: true,
+			},
+			Peers: []Peer{
+				{ID: "Laptop", PublicKey: "pubKey1", PresharedKey: "psk1", AllowedIPs: "192.0.2.2/32"},
+				{PublicKey: "pubKey2", Endpoint: "node2.example.tld:2", AllowedIPs: "192.0.2.3/32", PersistentKeepalive: 25},
+			},
+		}
+		data := Format(conf)
+
+		Convey("Empty fields should be omitted", func() {
+			So(string(data), ShouldEqual, `[Interface]
+# ID = Hub
+PrivateKey = priKey
+Address = 192.0.2.1/24
+ListenPort = 51820
+Table = off
+PostUp = /pos/up 1 %i
+PostUp = /pos/up 2 %i
+SaveConfig = true
+
+[Peer]
+# ID = Laptop
+PublicKey = pubKey1
+PresharedKey = psk1
+AllowedIPs = 192.0.2.2/32
+
+[Peer]
+PublicKey = pubKey2
+Endpoint = node2.example.tld:2
+AllowedIPs = 192.0.2.3/32
+PersistentKeepalive = 25
+`)
+		})
+		Convey("It should be parsed back to the same config", func() {
+			f, err := Parse(data)
+			So(err, ShouldBeNil)
+			parsed, unknown, err := f.Config()
+			So(err, ShouldBeNil)
+			So(unknown, ShouldBeEmpty)
+			So(parsed, ShouldResemble, conf)
+		})
+	})
+}
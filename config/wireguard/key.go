@@ -0,0 +1,41 @@
+package wireguard
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+const keyLen = 32
+
+// GenerateKey returns a new key pair encoded the same way as `wg genkey` and `wg pubkey`.
+func GenerateKey() (privateKey string, publicKey string, err error) {
+	key := make([]byte, keyLen)
+	if _, err := rand.Read(key); err != nil {
+		return "", "", fmt.Errorf("reading random bytes: %w", err)
+	}
+	// Clamp the key as `wg genkey` does.
+	key[0] &= 248
+	key[31] = (key[31] & 127) | 64
+
+	privateKey = base64.StdEncoding.EncodeToString(key)
+	publicKey, err = PublicKey(privateKey)
+	return privateKey, publicKey, err
+}
+
+// PublicKey returns the public key of given private key, like `wg pubkey` does.
+func PublicKey(privateKey string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("decoding private key: %w", err)
+	}
+	if len(key) != keyLen {
+		return "", fmt.Errorf("invalid private key length: %d", len(key))
+	}
+	priKey, err := ecdh.X25519().NewPrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("parsing private key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(priKey.PublicKey().Bytes()), nil
+}
@@ -0,0 +1,33 @@
+package wireguard
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKey(t *testing.T) {
+	Convey("Derive public key", t, func() {
+		// Test vector from RFC 7748.
+		pri, _ := hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+		pub, _ := hex.DecodeString("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+		publicKey, err := PublicKey(base64.StdEncoding.EncodeToString(pri))
+		So(err, ShouldBeNil)
+		So(publicKey, ShouldEqual, base64.StdEncoding.EncodeToString(pub))
+
+		_, err = PublicKey("not-a-key")
+		So(err, ShouldNotBeNil)
+	})
+	Convey("Generate key pairs", t, func() {
+		privateKey, publicKey, err := GenerateKey()
+		So(err, ShouldBeNil)
+		So(privateKey, ShouldHaveLength, 44)
+		So(publicKey, ShouldHaveLength, 44)
+
+		derived, err := PublicKey(privateKey)
+		So(err, ShouldBeNil)
+		So(derived, ShouldEqual, publicKey)
+	})
+}
@@ -0,0 +1,54 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnified(t *testing.T) {
+	Convey("Diff text files", t, func() {
+		a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+
+		Convey("Equal files should have no diff", func() {
+			So(Unified("a", "b", []byte(a), []byte(a), DefaultContext), ShouldBeEmpty)
+		})
+		Convey("Changes should be shown with context", func() {
+			b := strings.Replace(a, "6\n", "six\n", 1)
+			So(Unified("a", "b", []byte(a), []byte(b), DefaultContext), ShouldEqual, `--- a
++++ b
+@@ -3,7 +3,7 @@
+ 3
+ 4
+ 5
+-6
++six
+ 7
+ 8
+ 9
+`)
+		})
+		Convey("Distant changes should be in separate hunks", func() {
+			b := strings.Replace(strings.Replace(a, "1\n", "", 1), "12\n", "12\n13\n", 1)
+			So(Unified("a", "b", []byte(a), []byte(b), 1), ShouldEqual, `--- a
++++ b
+@@ -1,2 +1 @@
+-1
+ 2
+@@ -12 +11,2 @@
+ 12
++13
+`)
+		})
+		Convey("Creating a file", func() {
+			So(Unified("/dev/null", "b", nil, []byte("x\ny"), DefaultContext), ShouldEqual, `--- /dev/null
++++ b
+@@ -0,0 +1,2 @@
++x
++y
+\ No newline at end of file
+`)
+		})
+	})
+}
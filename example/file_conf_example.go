@@ -19,7 +19,7 @@ Subnet = 192.168.25.0/24
 
 # This is a client peer.
 [Peer]
-# The name of the peer, must be unique across networks.
+# The name of the peer, must be unique within the network, the same peer could join other networks with the same ID.
 ID = Tento
 # The WireGuard IP Address of the peer.
 Address = 192.168.25.55/32
@@ -29,11 +29,37 @@ Address = 192.168.25.55/32
 LocalSubnets = 10.1.1.0/24
 # PrivateKey of the peer, could be generated with:
 # prik=$(wg genkey); pubk=$(echo "$prik" | wg pubkey); echo -e "PrivateKey = $prik\nPublicKey = $pubk"
+# Omit this if the key is generated on the device and never leaves it,
+# the PrivateKey in the generated configuration of this peer is then a placeholder to be filled in on the device.
 PrivateKey = private-key-of-tento
 # PublicKey of the peer, could be generated like above.
 PublicKey = public-key-of-tento
 # Add this if THIS PEER is behind a NAT(no public IP), optional.
 PersistentKeepalive = 25
+# DNS servers and search domains used by the peer while connected, optional.
+# DNS = 192.168.25.1,corp.example.com
+# The peer is left out of the network since this time, useful for temporary access, optional.
+# Accepted formats: 2006-01-02, 2006-01-02 15:04 (local time) or 2006-01-02T15:04:05+08:00.
+# ExpiresAt = 2030-01-02
+# Set this to leave the peer out of the network without removing it, optional.
+# Disabled = true
+# PresharedKey for an additional layer of symmetric-key cryptography on the links to bounce servers, optional.
+# Could be generated with: wg genpsk
+# PresharedKey = preshared-key-of-tento
+# Formats of the rendered configs, a comma-separated list of wg-quick(default), networkd, networkmanager, openwrt, routeros, vyos, opnsense, nixos and mobileconfig, optional.
+# networkd renders wg-<network>.netdev and wg-<network>.network for systemd-networkd instead of wg-<network>.conf,
+# networkmanager renders wg-<network>.nmconnection for NetworkManager,
+# openwrt renders wg-<network>.network.uci and wg-<network>.firewall.uci, which is the default if OS is OpenWrt,
+# routeros renders the script wg-<network>.rsc, which is the default if OS is RouterOS,
+# vyos renders the commands wg-<network>.vyos, which is the default if OS is VyOS,
+# opnsense renders wg-<network>.opnsense.xml, which is the default if OS is OPNsense,
+# nixos renders the module wg-<network>.nix, which is the default if OS is NixOS,
+# mobileconfig renders the Apple profile wg-<network>.mobileconfig, which is the default if OS is iOS or macOS.
+# Output = wg-quick,networkd
+# Name of the WireGuard interface in configs other than wg-quick, wg-<network> by default, required by vyos, e.g. wg0, optional.
+# InterfaceName = wg0
+# Wi-Fi networks where the tunnel of mobileconfig is disconnected on demand, a comma-separated list, optional.
+# TrustedSSIDs = Office,Home
 
 
 # The peer acting as a server, relaying traffic for client peers.
@@ -58,7 +84,7 @@ AllowedIPs = 10.1.1.0/24
 #
 # Name of the network interface connecting to the Internet, used for adding packet forwarding rules.
 PublicInterface = eth0
-# Operating System, used to decide how to enable packet forwarding.
+# Operating System, used to decide how to enable packet forwarding, Linux, OpenWrt, RouterOS, VyOS, OPNsense, NixOS, iOS or macOS.
 OS = Linux
 
 
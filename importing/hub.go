@@ -0,0 +1,166 @@
+package importing
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/tevino/wg-make/config"
+)
+
+// ServerOptions completes the settings of the server missing from the data files of other tools.
+type ServerOptions struct {
+	// Subnet of the network, inferred from the address of the server if empty.
+	Subnet string
+	// Endpoint of the server, e.g. vpn.example.com:51820.
+	Endpoint string
+	// PublicInterface of the server, DefaultPublicInterface if empty.
+	PublicInterface string
+	// PersistentKeepalive of clients, 0 to keep the one in the data files.
+	PersistentKeepalive int
+}
+
+const ipv4Bits = 32
+
+// hubServerID is the ID of the server imported from tools managing a single server with its clients.
+const hubServerID = "server"
+
+// idSet contains the IDs taken by peers.
+type idSet map[string]bool
+
+// unique returns id, or id with a suffix if it's taken.
+func (ids idSet) unique(r *Result, id string) string {
+	unique := id
+	for i := 2; ids[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", id, i)
+	}
+	if unique != id {
+		r.problemf("peer ID %s is taken, %s is used instead", id, unique)
+	}
+	ids[unique] = true
+	return unique
+}
+
+// hub builds a network of a bounce server and its clients, which is what other tools manage.
+type hub struct {
+	r    *Result
+	conf *config.Config
+	ids  idSet
+}
+
+// newHub returns a hub with the server added, address is the one of the server in CIDR or a bare IP.
+func newHub(networkID string, server config.Peer, address string, opts ServerOptions) (*hub, error) {
+	h := &hub{r: &Result{}, ids: idSet{}}
+	ip, subnet, err := parseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address(%s) of the server: %w", address, err)
+	}
+	if opts.Subnet != "" {
+		if _, subnet, err = net.ParseCIDR(opts.Subnet); err != nil {
+			return nil, fmt.Errorf("invalid subnet(%s): %w", opts.Subnet, err)
+		}
+	} else if ones, bits := subnet.Mask.Size(); ones == bits {
+		// Tools allocate addresses of clients from the /24 (or /64 for IPv6) the server is in.
+		mask := net.CIDRMask(24, bits)
+		if bits != ipv4Bits {
+			mask = net.CIDRMask(64, bits)
+		}
+		subnet = &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+		h.r.problemf("subnet of the network is assumed to be %s", subnet)
+	}
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint of the server is required")
+	}
+	_, port, err := net.SplitHostPort(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint(%s): %w", opts.Endpoint, err)
+	}
+	if server.ListenPort == 0 {
+		if server.ListenPort, err = strconv.Atoi(port); err != nil {
+			return nil, fmt.Errorf("invalid port of endpoint(%s): %w", opts.Endpoint, err)
+		}
+	}
+	server.ID = h.ids.unique(h.r, hubServerID)
+	server.Address = hostAddress(ip)
+	server.Endpoint = opts.Endpoint
+	server.PublicInterface = opts.PublicInterface
+	if server.PublicInterface == "" {
+		server.PublicInterface = DefaultPublicInterface
+		h.r.problemf("peer %s: public interface of the bounce server is assumed to be %s", server.ID, DefaultPublicInterface)
+	}
+	// Both tools run the server on Linux.
+	server.OS = config.OSLinux
+	h.conf = &config.Config{
+		Network: config.Network{ID: networkID, Subnet: subnet.String()},
+		Peers:   []config.Peer{server},
+	}
+	return h, nil
+}
+
+// addClient adds a client named name with the first address in addresses, the rest are reported.
+// The unique ID of the client is returned for reporting problems of it.
+func (h *hub) addClient(name string, client config.Peer, addresses []string) string {
+	client.ID = h.ids.unique(h.r, sanitizeID(name, client.PublicKey))
+	for _, address := range addresses {
+		ip, _, err := parseAddress(address)
+		if err != nil {
+			h.r.problemf("peer %s: invalid address %s is ignored", client.ID, address)
+			continue
+		}
+		if client.Address != "" {
+			h.r.problemf("peer %s: only the first address is imported, %s is ignored", client.ID, address)
+			continue
+		}
+		client.Address = hostAddress(ip)
+	}
+	if client.Address == "" {
+		h.r.problemf("peer %s: skipped as it has no address", client.ID)
+		return client.ID
+	}
+	h.conf.Peers = append(h.conf.Peers, client)
+	return client.ID
+}
+
+// result validates and returns the network built.
+func (h *hub) result() (*Result, error) {
+	if err := h.conf.Validate(); err != nil {
+		return nil, fmt.Errorf("validating imported network(%s): %w", h.conf.Network.ID, err)
+	}
+	h.r.Config = h.conf
+	return h.r, nil
+}
+
+// parseAddress parses an address in CIDR or a bare IP, which is considered as a single host.
+func parseAddress(address string) (net.IP, *net.IPNet, error) {
+	address = strings.TrimSpace(address)
+	if !strings.Contains(address, "/") {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return nil, nil, fmt.Errorf("invalid IP address: %s", address)
+		}
+		_, subnet, err := net.ParseCIDR(hostAddress(ip))
+		return ip, subnet, err
+	}
+	return net.ParseCIDR(address)
+}
+
+// sanitizeID returns name usable as an ID of a peer, which is also a folder name.
+func sanitizeID(name string, publicKey string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.TrimSpace(name) {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' {
+			b.WriteRune(c)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	id := strings.Trim(b.String(), "-.")
+	if id == "" {
+		id = "peer-" + keyPrefix(publicKey)
+	}
+	return id
+}
@@ -0,0 +1,435 @@
+// Package importing builds network descriptions from configurations managed by other means.
+package importing
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/config/wireguard"
+)
+
+// DefaultPublicInterface is assumed for bounce servers whose public interface can't be inferred.
+const DefaultPublicInterface = "eth0"
+
+var rePublicInterface = regexp.MustCompile(`POSTROUTING -o (\S+) -j MASQUERADE`)
+
+// Result is a network description built by an importer.
+type Result struct {
+	Config *config.Config
+	// Problems that could not be reconciled, the description may need to be edited by hand.
+	Problems []string
+}
+
+func (r *Result) problemf(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// Source is the wg-quick configuration of a peer.
+type Source struct {
+	// Name of the source, e.g. the file name, it's the ID of the peer unless the interface is named in the config.
+	Name   string
+	Config *wireguard.Config
+}
+
+// view is a Peer section of a config, i.e. how a peer is seen by another.
+type view struct {
+	viewer *peer
+	*wireguard.Peer
+}
+
+// peer is a peer being imported.
+type peer struct {
+	config.Peer
+	ip net.IP
+	// Subnets of the addresses of the interface, used to infer the subnet of the network.
+	subnets []*net.IPNet
+	// source is nil for peers only found in Peer sections of others.
+	source *Source
+	views  []view
+}
+
+// FromWireGuard builds the description of a network from wg-quick configurations of its peers.
+//
+// Peers are matched across configs by public keys, those without a config are imported as public-key-only peers.
+// The subnet of the network is inferred from addresses and AllowedIPs if it's empty.
+// Peers with endpoints become bounce servers and AllowedIPs outside of the subnet become routes of peers.
+func FromWireGuard(networkID string, subnet string, sources []Source) (*Result, error) {
+	r := &Result{}
+	peers := []*peer{}
+	byKey := map[string]*peer{}
+	ids := idSet{}
+
+	for i := range sources {
+		src := &sources[i]
+		iface := &src.Config.Interface
+		publicKey, err := wireguard.PublicKey(iface.PrivateKey)
+		if err != nil {
+			r.problemf("%s: skipped as the public key can't be derived from the private key: %v", src.Name, err)
+			continue
+		}
+		if other, ok := byKey[publicKey]; ok {
+			r.problemf("%s: skipped as it has the same private key as peer %s", src.Name, other.ID)
+			continue
+		}
+		id := iface.ID
+		if id == "" {
+			id = src.Name
+		}
+		p := &peer{source: src}
+		p.ID = ids.unique(r, id)
+		p.PrivateKey = iface.PrivateKey
+		p.PublicKey = publicKey
+		p.ListenPort = iface.ListenPort
+		r.importInterface(p, iface)
+		peers = append(peers, p)
+		byKey[publicKey] = p
+	}
+
+	// Peers are matched by public keys, and keepalive is set on the side of the viewer.
+	withSources := peers
+	for _, viewer := range withSources {
+		keepalive := 0
+		for i := range viewer.source.Config.Peers {
+			wgPeer := &viewer.source.Config.Peers[i]
+			target, ok := byKey[wgPeer.PublicKey]
+			if !ok {
+				id := wgPeer.ID
+				if id == "" {
+					id = "peer-" + keyPrefix(wgPeer.PublicKey)
+				}
+				target = &peer{}
+				target.ID = ids.unique(r, id)
+				target.PublicKey = wgPeer.PublicKey
+				r.problemf("peer %s has no config, it's imported as a public-key-only peer", target.ID)
+				peers = append(peers, target)
+				byKey[wgPeer.PublicKey] = target
+			}
+			if target == viewer {
+				r.problemf("peer %s: Peer section of itself is ignored", viewer.ID)
+				continue
+			}
+			target.views = append(target.views, view{viewer: viewer, Peer: wgPeer})
+			if wgPeer.PersistentKeepalive != 0 && wgPeer.Endpoint != "" {
+				if keepalive != 0 && keepalive != wgPeer.PersistentKeepalive {
+					r.problemf("peer %s: PersistentKeepalive differs between peers, %d is used", viewer.ID, keepalive)
+					continue
+				}
+				keepalive = wgPeer.PersistentKeepalive
+			}
+		}
+		viewer.PersistentKeepalive = keepalive
+	}
+
+	for _, p := range peers {
+		r.resolveEndpoint(p)
+		if p.ip == nil {
+			r.resolveAddress(p)
+		}
+	}
+
+	subnetNet, err := resolveSubnet(subnet, peers)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &config.Config{Network: config.Network{ID: networkID, Subnet: subnetNet.String()}}
+	for _, p := range peers {
+		if p.ip == nil {
+			r.problemf("peer %s: skipped as its address can't be inferred", p.ID)
+			continue
+		}
+		if !subnetNet.Contains(p.ip) {
+			r.problemf("peer %s: skipped as its address %s is out of subnet %s", p.ID, p.Address, subnetNet)
+			continue
+		}
+		if p.Endpoint != "" && p.PublicInterface == "" {
+			p.PublicInterface = DefaultPublicInterface
+			r.problemf("peer %s: public interface of the bounce server is assumed to be %s", p.ID, DefaultPublicInterface)
+		}
+		conf.Peers = append(conf.Peers, p.Peer)
+	}
+	r.resolveRoutes(conf, peers, subnetNet)
+	r.resolvePresharedKeys(conf, peers)
+	r.checkLinks(conf, peers)
+	r.Config = conf
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("validating imported network(%s): %w", networkID, err)
+	}
+	return r, nil
+}
+
+// importInterface imports the address, hooks and the rest of the Interface section of a peer.
+func (r *Result) importInterface(p *peer, iface *wireguard.Interface) {
+	for _, address := range strings.Split(iface.Address, ",") {
+		if address == "" {
+			continue
+		}
+		ip, subnet, err := net.ParseCIDR(address)
+		if err != nil {
+			r.problemf("peer %s: invalid address %s is ignored", p.ID, address)
+			continue
+		}
+		if p.ip != nil {
+			r.problemf("peer %s: only the first address is imported, %s is ignored", p.ID, address)
+			continue
+		}
+		p.ip = ip
+		p.Address = hostAddress(ip)
+		if ones, bits := subnet.Mask.Size(); ones < bits {
+			p.subnets = append(p.subnets, subnet)
+		}
+	}
+
+	// Hooks generated by wg-make for bounce servers are recognized, the rest are dropped.
+	for _, hook := range append(iface.PostUps[:len(iface.PostUps):len(iface.PostUps)], iface.PostDowns...) {
+		switch {
+		case rePublicInterface.MatchString(hook):
+			p.PublicInterface = rePublicInterface.FindStringSubmatch(hook)[1]
+			p.OS = config.OSLinux
+		case strings.HasPrefix(hook, "sysctl "):
+		default:
+			r.problemf("peer %s: hook is not supported: %s", p.ID, hook)
+		}
+	}
+	if len(iface.PreUps)+len(iface.PreDowns) > 0 {
+		r.problemf("peer %s: PreUp and PreDown are not supported", p.ID)
+	}
+	p.DNS = iface.DNS
+	unsupported := map[string]bool{
+		"MTU":        iface.MTU != 0,
+		"Table":      iface.Table != "",
+		"FwMark":     iface.FwMark != "",
+		"SaveConfig": iface.SaveConfig,
+	}
+	for _, key := range []string{"MTU", "Table", "FwMark", "SaveConfig"} {
+		if unsupported[key] {
+			r.problemf("peer %s: %s is not supported", p.ID, key)
+		}
+	}
+}
+
+// resolveEndpoint sets the endpoint of p seen by others.
+func (r *Result) resolveEndpoint(p *peer) {
+	for _, v := range p.views {
+		if v.Endpoint == "" {
+			continue
+		}
+		if p.Endpoint != "" && p.Endpoint != v.Endpoint {
+			r.problemf("peer %s: endpoint differs between peers, %s is used instead of %s", p.ID, p.Endpoint, v.Endpoint)
+			continue
+		}
+		p.Endpoint = v.Endpoint
+	}
+}
+
+// resolveAddress sets the address of a peer without a config from the host routes others have for it.
+func (r *Result) resolveAddress(p *peer) {
+	for _, v := range p.views {
+		for _, allowedIP := range strings.Split(v.AllowedIPs, ",") {
+			ip, subnet, err := net.ParseCIDR(allowedIP)
+			if err != nil {
+				continue
+			}
+			if ones, bits := subnet.Mask.Size(); ones == bits {
+				p.ip = ip
+				p.Address = hostAddress(ip)
+				return
+			}
+		}
+	}
+}
+
+// resolveSubnet returns the given subnet, or the smallest one containing addresses of all peers.
+func resolveSubnet(subnet string, peers []*peer) (*net.IPNet, error) {
+	if subnet != "" {
+		_, subnetNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnet(%s): %w", subnet, err)
+		}
+		return subnetNet, nil
+	}
+	candidates := []*net.IPNet{}
+	for _, p := range peers {
+		candidates = append(candidates, p.subnets...)
+		for _, v := range p.views {
+			for _, allowedIP := range strings.Split(v.AllowedIPs, ",") {
+				if _, n, err := net.ParseCIDR(allowedIP); err == nil {
+					candidates = append(candidates, n)
+				}
+			}
+		}
+	}
+	var best *net.IPNet
+	for _, c := range candidates {
+		ones, _ := c.Mask.Size()
+		if ones == 0 || !containsAll(c, peers) {
+			continue
+		}
+		if best == nil {
+			best = c
+		} else if bestOnes, _ := best.Mask.Size(); ones > bestOnes {
+			best = c
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no subnet contains addresses of all peers, please specify one")
+	}
+	return best, nil
+}
+
+func containsAll(subnet *net.IPNet, peers []*peer) bool {
+	for _, p := range peers {
+		if p.ip != nil && !subnet.Contains(p.ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveRoutes sets AllowedIPs of peers to the routes out of the subnet others have for them.
+//
+// Routes claimed by more than one peer are given to non-bounce servers,
+// as bounce servers relay routes of the peers behind them.
+func (r *Result) resolveRoutes(conf *config.Config, peers []*peer, subnet *net.IPNet) {
+	claims := map[string][]string{}
+	routes := []string{}
+	for _, p := range peers {
+		for _, v := range p.views {
+			for _, allowedIP := range strings.Split(v.AllowedIPs, ",") {
+				_, n, err := net.ParseCIDR(allowedIP)
+				if err != nil || isWithin(n, subnet) {
+					continue
+				}
+				// Default routes and the ones covering the whole subnet can't be described.
+				if n.Contains(subnet.IP) {
+					r.problemf("peer %s: route %s towards %s is not supported", v.viewer.ID, n, p.ID)
+					continue
+				}
+				route := n.String()
+				if !hasString(claims[route], p.ID) {
+					if len(claims[route]) == 0 {
+						routes = append(routes, route)
+					}
+					claims[route] = append(claims[route], p.ID)
+				}
+			}
+		}
+	}
+
+	for _, route := range routes {
+		owners := []string{}
+		for _, id := range claims[route] {
+			if p, ok := conf.GetPeerByID(id); ok && !p.IsBounceServer() {
+				owners = append(owners, id)
+			}
+		}
+		if len(owners) == 0 {
+			owners = claims[route]
+		}
+		if len(owners) > 1 {
+			r.problemf("route %s is claimed by peers %s, it's given to %s", route, strings.Join(owners, ", "), owners[0])
+		}
+		if p, ok := conf.GetPeerByID(owners[0]); ok {
+			if p.AllowedIPs != "" {
+				p.AllowedIPs += ","
+			}
+			p.AllowedIPs += route
+		}
+	}
+}
+
+// resolvePresharedKeys sets PresharedKey of client peers to the ones used on their links.
+func (r *Result) resolvePresharedKeys(conf *config.Config, peers []*peer) {
+	for _, p := range peers {
+		for _, v := range p.views {
+			if v.PresharedKey == "" {
+				continue
+			}
+			a, okA := conf.GetPeerByID(v.viewer.ID)
+			b, okB := conf.GetPeerByID(p.ID)
+			if !okA || !okB {
+				continue
+			}
+			client := a
+			if a.IsBounceServer() {
+				client = b
+			}
+			switch {
+			case client.IsBounceServer():
+				r.problemf("PresharedKey between bounce servers %s and %s is not supported", a.ID, b.ID)
+			case client.PresharedKey == "":
+				client.PresharedKey = v.PresharedKey
+			case client.PresharedKey != v.PresharedKey:
+				r.problemf("peer %s: PresharedKey differs between links, only one is kept", client.ID)
+			}
+		}
+	}
+}
+
+// checkLinks reports the differences between the links among peers in configs and the ones wg-make renders.
+func (r *Result) checkLinks(conf *config.Config, peers []*peer) {
+	linked := map[[2]string]bool{}
+	for _, p := range peers {
+		for _, v := range p.views {
+			linked[[2]string{v.viewer.ID, p.ID}] = true
+		}
+	}
+	for i := range conf.Peers {
+		a := &conf.Peers[i]
+		for j := range conf.Peers {
+			b := &conf.Peers[j]
+			if a.ID >= b.ID {
+				continue
+			}
+			configured := linked[[2]string{a.ID, b.ID}] || linked[[2]string{b.ID, a.ID}]
+			switch {
+			case configured && !a.IsLinkedTo(b):
+				r.problemf("link between %s and %s is dropped as neither of them is a bounce server", a.ID, b.ID)
+			case !configured && a.IsLinkedTo(b):
+				r.problemf("peers %s and %s are linked as one of them is a bounce server", a.ID, b.ID)
+			}
+		}
+	}
+}
+
+// isWithin returns true if n is a part of subnet.
+func isWithin(n *net.IPNet, subnet *net.IPNet) bool {
+	ones, _ := n.Mask.Size()
+	subnetOnes, _ := subnet.Mask.Size()
+	return subnet.Contains(n.IP) && ones >= subnetOnes
+}
+
+// hostAddress returns ip with the mask of a single host.
+func hostAddress(ip net.IP) string {
+	if ip.To4() != nil {
+		return ip.String() + "/32"
+	}
+	return ip.String() + "/128"
+}
+
+// keyPrefix returns the leading alphanumeric characters of a key, usable as a part of IDs.
+func keyPrefix(key string) string {
+	prefix := []rune{}
+	for _, c := range key {
+		if len(prefix) == 8 {
+			break
+		}
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			prefix = append(prefix, c)
+		}
+	}
+	return string(prefix)
+}
+
+func hasString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package importing
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/config/wireguard"
+	"github.com/tevino/wg-make/rendering"
+)
+
+func newPeer(id, address string) config.Peer {
+	p := config.Peer{ID: id}
+	p.Address = address
+	p.PrivateKey, p.PublicKey, _ = wireguard.GenerateKey()
+	return p
+}
+
+// sourcesOf returns the rendered configs of peers of a network as sources.
+func sourcesOf(conf *config.Config, ids ...string) []Source {
+	sources := []Source{}
+	for _, id := range ids {
+		wgConf, err := rendering.BuildPeerConfig(conf, id, time.Now())
+		So(err, ShouldBeNil)
+		f, err := wireguard.Parse(wireguard.Format(wgConf))
+		So(err, ShouldBeNil)
+		parsed, _, err := f.Config()
+		So(err, ShouldBeNil)
+		// Names are not always available in configs written by hand.
+		parsed.Interface.ID = ""
+		for i := range parsed.Peers {
+			parsed.Peers[i].ID = ""
+		}
+		sources = append(sources, Source{Name: id, Config: parsed})
+	}
+	return sources
+}
+
+func TestFromWireGuard(t *testing.T) {
+	Convey("Import configs of a rendered network", t, func() {
+		hub := newPeer("hub", "10.8.0.1/32")
+		hub.Endpoint = "hub.example.com:51820"
+		hub.ListenPort = 51820
+		hub.PublicInterface = "ens3"
+		hub.OS = config.OSLinux
+		office := newPeer("office", "10.8.0.2/32")
+		office.AllowedIPs = "192.168.1.0/24"
+		office.PersistentKeepalive = 25
+		office.PresharedKey = "psk-of-office"
+		laptop := newPeer("laptop", "10.8.0.3/32")
+		laptop.PersistentKeepalive = 25
+		phone := newPeer("phone", "10.8.0.4/32")
+		phone.PrivateKey = ""
+		conf := &config.Config{
+			Network: config.Network{ID: "corp", Subnet: "10.8.0.0/24"},
+			Peers:   []config.Peer{hub, office, laptop, phone},
+		}
+		So(conf.Validate(), ShouldBeNil)
+
+		r, err := FromWireGuard("corp", "", sourcesOf(conf, "hub", "office", "laptop"))
+		So(err, ShouldBeNil)
+		imported := r.Config
+
+		Convey("The subnet should be inferred", func() {
+			So(imported.Network, ShouldResemble, conf.Network)
+		})
+		Convey("Peers should be inferred", func() {
+			So(imported.Peers, ShouldHaveLength, 4)
+			So(imported.Peers[:3], ShouldResemble, conf.Peers[:3])
+		})
+		Convey("Peers without configs should be public-key-only", func() {
+			p := imported.Peers[3]
+			So(p.ID, ShouldStartWith, "peer-")
+			So(p.Address, ShouldEqual, phone.Address)
+			So(p.IsPublicKeyOnly(), ShouldBeTrue)
+			So(r.Problems, ShouldHaveLength, 1)
+			So(r.Problems[0], ShouldContainSubstring, "public-key-only")
+		})
+		Convey("It should re-render to equivalent configs", func() {
+			for _, id := range []string{"hub", "office", "laptop"} {
+				expected, err := rendering.BuildPeerConfig(conf, id, time.Now())
+				So(err, ShouldBeNil)
+				actual, err := rendering.BuildPeerConfig(imported, id, time.Now())
+				So(err, ShouldBeNil)
+				So(actual.Interface, ShouldResemble, expected.Interface)
+				So(actual.Peers, ShouldHaveLength, len(expected.Peers))
+				for i := range actual.Peers {
+					actual.Peers[i].ID = expected.Peers[i].ID
+				}
+				So(actual.Peers, ShouldResemble, expected.Peers)
+			}
+		})
+	})
+
+	Convey("Problems should be reported", t, func() {
+		a := newPeer("a", "10.9.0.1/24")
+		b := newPeer("b", "10.9.0.2/24")
+		srcA := &wireguard.Config{Interface: a.Interface}
+		srcA.Interface.DNS = "1.1.1.1"
+		srcA.Interface.MTU = 1420
+		srcA.Peers = []wireguard.Peer{{PublicKey: b.PublicKey, AllowedIPs: "10.9.0.2/32", PresharedKey: "psk"}}
+		srcB := &wireguard.Config{Interface: b.Interface}
+		srcB.Peers = []wireguard.Peer{{PublicKey: a.PublicKey, AllowedIPs: "10.9.0.1/32"}}
+		broken := &wireguard.Config{Interface: wireguard.Interface{PrivateKey: "invalid"}}
+
+		r, err := FromWireGuard("lan", "", []Source{{Name: "a", Config: srcA}, {Name: "b", Config: srcB}, {Name: "c", Config: broken}})
+		So(err, ShouldBeNil)
+		So(r.Config.Network.Subnet, ShouldEqual, "10.9.0.0/24")
+		So(r.Config.Peers, ShouldHaveLength, 2)
+		So(r.Config.Peers[0].Address, ShouldEqual, "10.9.0.1/32")
+		So(r.Config.Peers[0].PresharedKey, ShouldEqual, "psk")
+		So(r.Config.Peers[0].DNS, ShouldEqual, "1.1.1.1")
+		So(r.Problems, ShouldHaveLength, 3)
+		So(r.Problems[0], ShouldStartWith, "peer a: MTU")
+		So(r.Problems[1], ShouldStartWith, "c: skipped")
+		So(r.Problems[2], ShouldEqual, "link between a and b is dropped as neither of them is a bounce server")
+	})
+
+	Convey("The subnet can't be inferred without enough information", t, func() {
+		a := newPeer("a", "10.9.0.1/32")
+		b := newPeer("b", "10.10.0.1/32")
+		_, err := FromWireGuard("lan", "", []Source{
+			{Name: "a", Config: &wireguard.Config{Interface: a.Interface}},
+			{Name: "b", Config: &wireguard.Config{Interface: b.Interface}},
+		})
+		So(err, ShouldNotBeNil)
+	})
+}
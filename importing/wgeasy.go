@@ -0,0 +1,85 @@
+package importing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/tevino/wg-make/config"
+)
+
+// wgEasyData reflects wg0.json of wg-easy.
+type wgEasyData struct {
+	Server struct {
+		PrivateKey string `json:"privateKey"`
+		PublicKey  string `json:"publicKey"`
+		Address    string `json:"address"`
+	} `json:"server"`
+	Clients map[string]wgEasyClient `json:"clients"`
+}
+
+type wgEasyClient struct {
+	Name         string `json:"name"`
+	Address      string `json:"address"`
+	PrivateKey   string `json:"privateKey"`
+	PublicKey    string `json:"publicKey"`
+	PreSharedKey string `json:"preSharedKey"`
+	Enabled      bool   `json:"enabled"`
+	// ExpiredAt is only available in recent versions.
+	ExpiredAt *string `json:"expiredAt"`
+}
+
+// FromWGEasy builds the description of a network from wg0.json of wg-easy.
+//
+// Settings of the server not in wg0.json, e.g. WG_HOST and WG_PORT, are given by opts.
+// The server becomes a bounce server, and clients keep their addresses, keys and enabled state.
+func FromWGEasy(networkID string, data []byte, opts ServerOptions) (*Result, error) {
+	var d wgEasyData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("parsing wg-easy data: %w", err)
+	}
+	var server config.Peer
+	server.PrivateKey = d.Server.PrivateKey
+	server.PublicKey = d.Server.PublicKey
+	h, err := newHub(networkID, server, d.Server.Address, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clients are sorted by addresses as they are keyed by random IDs.
+	clients := make([]wgEasyClient, 0, len(d.Clients))
+	for _, c := range d.Clients {
+		clients = append(clients, c)
+	}
+	sort.Slice(clients, func(i, j int) bool { return lessIP(clients[i].Address, clients[j].Address) })
+	for _, c := range clients {
+		var client config.Peer
+		client.PrivateKey = c.PrivateKey
+		client.PublicKey = c.PublicKey
+		client.PresharedKey = c.PreSharedKey
+		client.PersistentKeepalive = opts.PersistentKeepalive
+		client.Disabled = !c.Enabled
+		if c.ExpiredAt != nil {
+			client.ExpiresAt = *c.ExpiredAt
+		}
+		h.addClient(c.Name, client, []string{c.Address})
+	}
+	return h.result()
+}
+
+// lessIP compares addresses numerically, invalid ones are compared as strings.
+func lessIP(a, b string) bool {
+	ipA, _, errA := parseAddress(a)
+	ipB, _, errB := parseAddress(b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	ipA, ipB = ipA.To16(), ipB.To16()
+	for i := 0; i < net.IPv6len; i++ {
+		if ipA[i] != ipB[i] {
+			return ipA[i] < ipB[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package importing
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const wgEasyJSON = `{
+  "server": {
+    "privateKey": "private-key-of-server",
+    "publicKey": "public-key-of-server",
+    "address": "10.8.0.1"
+  },
+  "clients": {
+    "6f3b0f35-2a2c-4c6a-9f5e-1b2a8c3d4e5f": {
+      "id": "6f3b0f35-2a2c-4c6a-9f5e-1b2a8c3d4e5f",
+      "name": "Old Phone",
+      "address": "10.8.0.10",
+      "privateKey": "private-key-of-old-phone",
+      "publicKey": "public-key-of-old-phone",
+      "preSharedKey": "psk-of-old-phone",
+      "enabled": false
+    },
+    "0b9e1f1a-8c1e-4d39-9a46-5f4d3e2c1b0a": {
+      "id": "0b9e1f1a-8c1e-4d39-9a46-5f4d3e2c1b0a",
+      "name": "John's Laptop",
+      "address": "10.8.0.2",
+      "privateKey": "private-key-of-laptop",
+      "publicKey": "public-key-of-laptop",
+      "preSharedKey": "psk-of-laptop",
+      "enabled": true,
+      "expiredAt": "2030-01-02T00:00:00.000Z"
+    }
+  }
+}`
+
+func TestFromWGEasy(t *testing.T) {
+	Convey("Import wg0.json of wg-easy", t, func() {
+		r, err := FromWGEasy("easy", []byte(wgEasyJSON), ServerOptions{Endpoint: "vpn.example.com:51820", PublicInterface: "ens3"})
+		So(err, ShouldBeNil)
+		conf := r.Config
+
+		So(conf.Network.Subnet, ShouldEqual, "10.8.0.0/24")
+		So(r.Problems, ShouldResemble, []string{"subnet of the network is assumed to be 10.8.0.0/24"})
+		So(conf.Peers, ShouldHaveLength, 3)
+
+		Convey("The server should be a bounce server", func() {
+			server := conf.Peers[0]
+			So(server.ID, ShouldEqual, "server")
+			So(server.IsBounceServer(), ShouldBeTrue)
+			So(server.Address, ShouldEqual, "10.8.0.1/32")
+			So(server.ListenPort, ShouldEqual, 51820)
+			So(server.PublicInterface, ShouldEqual, "ens3")
+			So(server.PrivateKey, ShouldEqual, "private-key-of-server")
+		})
+		Convey("Clients should keep keys, addresses and states", func() {
+			laptop, old := conf.Peers[1], conf.Peers[2]
+			So(laptop.ID, ShouldEqual, "John-s-Laptop")
+			So(laptop.Address, ShouldEqual, "10.8.0.2/32")
+			So(laptop.PrivateKey, ShouldEqual, "private-key-of-laptop")
+			So(laptop.PresharedKey, ShouldEqual, "psk-of-laptop")
+			So(laptop.ExpiresAt, ShouldEqual, "2030-01-02T00:00:00.000Z")
+			So(laptop.Disabled, ShouldBeFalse)
+
+			So(old.ID, ShouldEqual, "Old-Phone")
+			So(old.Disabled, ShouldBeTrue)
+			So(old.PresharedKey, ShouldEqual, "psk-of-old-phone")
+		})
+	})
+
+	Convey("The endpoint is required", t, func() {
+		_, err := FromWGEasy("easy", []byte(wgEasyJSON), ServerOptions{})
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,170 @@
+package importing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tevino/wg-make/config"
+)
+
+// Default settings of wireguard-ui, which are not reported as unsupported.
+const (
+	wireGuardUIDefaultFwMark = "0xca6c"
+	wireGuardUIDefaultTable  = "auto"
+)
+
+type wireGuardUIKeyPair struct {
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+type wireGuardUIInterface struct {
+	Addresses  []string `json:"addresses"`
+	ListenPort int      `json:"listen_port"`
+	PostUp     string   `json:"post_up"`
+	PreDown    string   `json:"pre_down"`
+	PostDown   string   `json:"post_down"`
+}
+
+type wireGuardUIGlobalSettings struct {
+	EndpointAddress     string   `json:"endpoint_address"`
+	DNSServers          []string `json:"dns_servers"`
+	MTU                 int      `json:"mtu"`
+	PersistentKeepalive int      `json:"persistent_keepalive"`
+	FirewallMark        string   `json:"firewall_mark"`
+	Table               string   `json:"table"`
+}
+
+type wireGuardUIClient struct {
+	Name            string   `json:"name"`
+	PrivateKey      string   `json:"private_key"`
+	PublicKey       string   `json:"public_key"`
+	PresharedKey    string   `json:"preshared_key"`
+	AllocatedIPs    []string `json:"allocated_ips"`
+	AllowedIPs      []string `json:"allowed_ips"`
+	ExtraAllowedIPs []string `json:"extra_allowed_ips"`
+	Enabled         bool     `json:"enabled"`
+}
+
+// FromWireGuardUI builds the description of a network from the JSON database folder of wireguard-ui.
+//
+// The endpoint and keepalive in global settings are used unless given by opts.
+// The server becomes a bounce server, and clients keep their addresses, keys, enabled state
+// and the extra AllowedIPs routed to them.
+func FromWireGuardUI(networkID string, dbDir string, opts ServerOptions) (*Result, error) {
+	var keyPair wireGuardUIKeyPair
+	var iface wireGuardUIInterface
+	var settings wireGuardUIGlobalSettings
+	for name, v := range map[string]interface{}{
+		"keypair.json":         &keyPair,
+		"interfaces.json":      &iface,
+		"global_settings.json": &settings,
+	} {
+		if err := readJSON(filepath.Join(dbDir, "server", name), v); err != nil {
+			return nil, err
+		}
+	}
+	if len(iface.Addresses) == 0 {
+		return nil, fmt.Errorf("no address of the server in %s", dbDir)
+	}
+
+	if opts.Endpoint == "" && settings.EndpointAddress != "" {
+		opts.Endpoint = settings.EndpointAddress
+		if _, _, err := net.SplitHostPort(opts.Endpoint); err != nil {
+			opts.Endpoint = net.JoinHostPort(settings.EndpointAddress, strconv.Itoa(iface.ListenPort))
+		}
+	}
+	if opts.PersistentKeepalive == 0 {
+		opts.PersistentKeepalive = settings.PersistentKeepalive
+	}
+	var server config.Peer
+	server.PrivateKey = keyPair.PrivateKey
+	server.PublicKey = keyPair.PublicKey
+	server.ListenPort = iface.ListenPort
+	h, err := newHub(networkID, server, iface.Addresses[0], opts)
+	if err != nil {
+		return nil, err
+	}
+	r := h.r
+	if len(iface.Addresses) > 1 {
+		r.problemf("peer %s: only the first address is imported, %s ignored", hubServerID, strings.Join(iface.Addresses[1:], ", "))
+	}
+	if iface.PostUp != "" || iface.PreDown != "" || iface.PostDown != "" {
+		r.problemf("peer %s: hooks are not supported", hubServerID)
+	}
+	if settings.MTU != 0 {
+		r.problemf("MTU is not supported")
+	}
+	if settings.FirewallMark != "" && settings.FirewallMark != wireGuardUIDefaultFwMark {
+		r.problemf("FwMark is not supported")
+	}
+	if settings.Table != "" && settings.Table != wireGuardUIDefaultTable {
+		r.problemf("Table is not supported")
+	}
+
+	clients, err := readWireGuardUIClients(filepath.Join(dbDir, "clients"))
+	if err != nil {
+		return nil, err
+	}
+	_, subnet, _ := net.ParseCIDR(h.conf.Network.Subnet)
+	for _, c := range clients {
+		var client config.Peer
+		client.PrivateKey = c.PrivateKey
+		client.PublicKey = c.PublicKey
+		client.PresharedKey = c.PresharedKey
+		client.PersistentKeepalive = opts.PersistentKeepalive
+		client.DNS = strings.Join(settings.DNSServers, ",")
+		client.Disabled = !c.Enabled
+		client.AllowedIPs = strings.Join(c.ExtraAllowedIPs, ",")
+		id := h.addClient(c.Name, client, c.AllocatedIPs)
+		for _, allowedIP := range c.AllowedIPs {
+			if _, n, err := net.ParseCIDR(allowedIP); err != nil || !isWithin(n, subnet) {
+				r.problemf("peer %s: route %s is not supported", id, allowedIP)
+			}
+		}
+	}
+	return h.result()
+}
+
+// readWireGuardUIClients reads clients in dir sorted by their addresses.
+func readWireGuardUIClients(dir string) ([]wireGuardUIClient, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading clients dir(%s): %w", dir, err)
+	}
+	clients := []wireGuardUIClient{}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		var c wireGuardUIClient
+		if err := readJSON(filepath.Join(dir, f.Name()), &c); err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+	sort.SliceStable(clients, func(i, j int) bool {
+		if len(clients[i].AllocatedIPs) == 0 || len(clients[j].AllocatedIPs) == 0 {
+			return len(clients[i].AllocatedIPs) > len(clients[j].AllocatedIPs)
+		}
+		return lessIP(clients[i].AllocatedIPs[0], clients[j].AllocatedIPs[0])
+	})
+	return clients, nil
+}
+
+func readJSON(filePath string, v interface{}) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("reading file(%s): %w", filePath, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing file(%s): %w", filePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package importing
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var wireGuardUIDB = map[string]string{
+	"server/keypair.json": `{"private_key": "private-key-of-server", "public_key": "public-key-of-server"}`,
+	"server/interfaces.json": `{"addresses": ["10.252.1.0/24"], "listen_port": 51820,
+		"post_up": "", "pre_down": "", "post_down": ""}`,
+	"server/global_settings.json": `{"endpoint_address": "vpn.example.com", "dns_servers": ["1.1.1.1"], "mtu": 1450,
+		"persistent_keepalive": 15, "firewall_mark": "0xca6c", "table": "auto"}`,
+	"clients/c1.json": `{"name": "office", "private_key": "private-key-of-office", "public_key": "public-key-of-office",
+		"preshared_key": "psk-of-office", "allocated_ips": ["10.252.1.2/32"], "allowed_ips": ["10.252.1.0/24"],
+		"extra_allowed_ips": ["192.168.10.0/24"], "enabled": true}`,
+	"clients/c2.json": `{"name": "Bob's phone", "private_key": "", "public_key": "public-key-of-phone",
+		"preshared_key": "", "allocated_ips": ["10.252.1.1/32"], "allowed_ips": ["0.0.0.0/0"],
+		"extra_allowed_ips": [], "enabled": false}`,
+}
+
+func TestFromWireGuardUI(t *testing.T) {
+	Convey("Import the database of wireguard-ui", t, func() {
+		dbDir, err := ioutil.TempDir("", "wg-make-test-")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dbDir)
+		for name, content := range wireGuardUIDB {
+			filePath := path.Join(dbDir, name)
+			So(os.MkdirAll(path.Dir(filePath), 0700), ShouldBeNil)
+			So(ioutil.WriteFile(filePath, []byte(content), 0600), ShouldBeNil)
+		}
+
+		r, err := FromWireGuardUI("ui", dbDir, ServerOptions{PublicInterface: "eth1"})
+		So(err, ShouldBeNil)
+		conf := r.Config
+		So(conf.Network.Subnet, ShouldEqual, "10.252.1.0/24")
+		So(conf.Peers, ShouldHaveLength, 3)
+
+		Convey("The server should be a bounce server at the endpoint in global settings", func() {
+			server := conf.Peers[0]
+			So(server.IsBounceServer(), ShouldBeTrue)
+			So(server.Endpoint, ShouldEqual, "vpn.example.com:51820")
+			So(server.Address, ShouldEqual, "10.252.1.0/32")
+			So(server.PublicInterface, ShouldEqual, "eth1")
+		})
+		Convey("Clients should be sorted by addresses and keep their states", func() {
+			phone, office := conf.Peers[1], conf.Peers[2]
+			So(phone.ID, ShouldEqual, "Bob-s-phone")
+			So(phone.IsPublicKeyOnly(), ShouldBeTrue)
+			So(phone.Disabled, ShouldBeTrue)
+			So(phone.PersistentKeepalive, ShouldEqual, 15)
+			So(phone.DNS, ShouldEqual, "1.1.1.1")
+
+			So(office.ID, ShouldEqual, "office")
+			So(office.Address, ShouldEqual, "10.252.1.2/32")
+			So(office.PresharedKey, ShouldEqual, "psk-of-office")
+			So(office.AllowedIPs, ShouldEqual, "192.168.10.0/24")
+			So(office.Disabled, ShouldBeFalse)
+		})
+		Convey("Unsupported settings should be reported", func() {
+			So(r.Problems, ShouldResemble, []string{
+				"MTU is not supported",
+				"peer Bob-s-phone: route 0.0.0.0/0 is not supported",
+			})
+		})
+	})
+}
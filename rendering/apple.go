@@ -0,0 +1,96 @@
+package rendering
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"text/template"
+
+	"github.com/tevino/wg-make/config"
+)
+
+// tplWgQuickConfig is the wg-quick config without the header, which is embedded in Apple profiles.
+var tplWgQuickConfig = template.Must(template.New("wg-quick").Parse(fileTplPrivateKeyPlaceholder + fileTplWgNetwork))
+
+// WgQuickConfig returns the wg-quick config of the peer, which is imported by the WireGuard app from profiles.
+func (c *PeerConfigTplContext) WgQuickConfig() (string, error) {
+	var buf bytes.Buffer
+	if err := tplWgQuickConfig.Execute(&buf, c); err != nil {
+		return "", fmt.Errorf("rendering wg-quick config: %w", err)
+	}
+	return strings.TrimSpace(buf.String()) + "\n", nil
+}
+
+// AppleVPNSubType returns the bundle ID of the WireGuard app on the OS of the peer.
+func (c *PeerConfigTplContext) AppleVPNSubType() string {
+	if c.Peer != nil && c.Peer.OS == config.OSMacOS {
+		return "com.wireguard.macos"
+	}
+	return "com.wireguard.ios"
+}
+
+// AppleIdentifier returns the identifier of the payload of kind in reverse-DNS style.
+func (c *PeerConfigTplContext) AppleIdentifier(kind string) string {
+	return "com.github.tevino.wg-make." + c.Network.ID + "." + c.Config.Interface.ID + "." + kind
+}
+
+// AppleUUID returns the UUID of the payload of kind.
+func (c *PeerConfigTplContext) AppleUUID(kind string) string {
+	return nameUUID("apple:" + c.Network.ID + ":" + c.Config.Interface.ID + ":" + kind)
+}
+
+// AppleRemoteAddress returns the host of the first endpoint, which is shown as the server by iOS and macOS.
+func (c *PeerConfigTplContext) AppleRemoteAddress() string {
+	for _, p := range c.Config.Peers {
+		if p.Endpoint != "" {
+			return c.EndpointHost(p.Endpoint)
+		}
+	}
+	return c.Network.ID
+}
+
+// AppleTrustedSSIDs returns the Wi-Fi networks where the tunnel is disconnected on demand.
+func (c *PeerConfigTplContext) AppleTrustedSSIDs() []string {
+	if c.Peer == nil {
+		return nil
+	}
+	return c.SplitList(c.Peer.TrustedSSIDs)
+}
+
+// AppleLocalDNSMatches returns the patterns of DNS servers in LocalSubnets of the peer,
+// the tunnel is disconnected on demand if DNS servers of the current network match one of them.
+//
+// Patterns only have wildcards in place of whole octets, so IPv4 subnets are expanded to subnets of /8, /16 or /24,
+// IPv6 subnets and subnets smaller than /24 are left out.
+func (c *PeerConfigTplContext) AppleLocalDNSMatches() []string {
+	if c.Peer == nil {
+		return nil
+	}
+	patterns := []string{}
+	for _, subnet := range c.SplitList(c.Peer.LocalSubnets) {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil || ipNet.IP.To4() == nil {
+			continue
+		}
+		ones, _ := ipNet.Mask.Size()
+		if ones > 24 {
+			continue
+		}
+		octets := (ones + 7) / 8
+		if octets == 0 {
+			return []string{"*"}
+		}
+		ip := ipNet.IP.To4()
+		count := 1 << uint(octets*8-ones)
+		for i := 0; i < count; i++ {
+			parts := make([]string, 0, octets+1)
+			for j := 0; j < octets-1; j++ {
+				parts = append(parts, fmt.Sprint(ip[j]))
+			}
+			parts = append(parts, fmt.Sprint(int(ip[octets-1])+i), "*")
+			patterns = append(patterns, strings.Join(parts, "."))
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,137 @@
+package rendering
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// pendingFile is a file to be written by writeFiles.
+type pendingFile struct {
+	path    string
+	content []byte
+	mode    os.FileMode
+}
+
+// stagedFile is a file written to a temporary path, waiting to be moved to its final path.
+type stagedFile struct {
+	tmpPath    string
+	finalPath  string
+	backupPath string
+}
+
+// writeFiles writes either all of the files or none of them.
+//
+// Files are written to temporary files beside the final paths first,
+// then swapped in only once all of them have been written.
+// Folders are created if necessary and removed again on failure.
+func writeFiles(files []pendingFile) (err error) {
+	var createdDirs []string
+	var staged []*stagedFile
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, f := range staged {
+			os.Remove(f.tmpPath)
+		}
+		for i := len(createdDirs) - 1; i >= 0; i-- {
+			os.Remove(createdDirs[i])
+		}
+	}()
+
+	for _, f := range files {
+		dir := path.Dir(f.path)
+		if missing := missingDirs(dir); len(missing) > 0 {
+			// Ancestors are recorded first, as MkdirAll could fail after creating some of them.
+			createdDirs = append(createdDirs, missing...)
+			if err := os.MkdirAll(dir, fileModeSensitive); err != nil {
+				return fmt.Errorf("creating folder(%s): %w", dir, err)
+			}
+		}
+		tmpPath, err := writeTempFile(dir, path.Base(f.path), f.content, f.mode)
+		if err != nil {
+			return fmt.Errorf("writing temporary file for %s: %w", f.path, err)
+		}
+		staged = append(staged, &stagedFile{tmpPath: tmpPath, finalPath: f.path})
+	}
+	return commitFiles(staged)
+}
+
+// missingDirs returns dir and its ancestors which don't exist yet, the outermost first.
+func missingDirs(dir string) []string {
+	var missing []string
+	for ; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			break
+		}
+		missing = append([]string{dir}, missing...)
+	}
+	return missing
+}
+
+func writeTempFile(dir string, name string, content []byte, mode os.FileMode) (string, error) {
+	tmp, err := ioutil.TempFile(dir, "."+name+".tmp-")
+	if err != nil {
+		return "", err
+	}
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// commitFiles moves staged files to their final paths, the existing files are restored on failure.
+func commitFiles(staged []*stagedFile) (err error) {
+	committed := 0
+	defer func() {
+		if err != nil {
+			// Roll back in reverse order.
+			for i := committed - 1; i >= 0; i-- {
+				f := staged[i]
+				if f.backupPath != "" {
+					os.Rename(f.backupPath, f.finalPath)
+				} else {
+					os.Remove(f.finalPath)
+				}
+			}
+			return
+		}
+		for _, f := range staged {
+			if f.backupPath != "" {
+				os.Remove(f.backupPath)
+			}
+		}
+	}()
+
+	for _, f := range staged {
+		if _, err := os.Stat(f.finalPath); err == nil {
+			backupPath := f.tmpPath + ".bak"
+			if err := os.Rename(f.finalPath, backupPath); err != nil {
+				return fmt.Errorf("backing up %s: %w", f.finalPath, err)
+			}
+			f.backupPath = backupPath
+		}
+		if err := os.Rename(f.tmpPath, f.finalPath); err != nil {
+			// Restore the current file right away as it's not counted as committed.
+			if f.backupPath != "" {
+				os.Rename(f.backupPath, f.finalPath)
+			}
+			return fmt.Errorf("replacing %s: %w", f.finalPath, err)
+		}
+		committed++
+	}
+	return nil
+}
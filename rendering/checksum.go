@@ -0,0 +1,72 @@
+package rendering
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const checksumLinePrefix = "# Checksum: sha256:"
+
+// addChecksum returns content with a checksum line inserted at the end of the leading comments.
+func addChecksum(content []byte) []byte {
+	line := fmt.Sprintf("%s%s\n", checksumLinePrefix, checksum(content))
+	i := bytes.Index(content, []byte("\n\n"))
+	if i < 0 {
+		return append([]byte(line), content...)
+	}
+	dst := make([]byte, 0, len(content)+len(line))
+	dst = append(dst, content[:i+1]...)
+	dst = append(dst, line...)
+	return append(dst, content[i+1:]...)
+}
+
+// IsModifiedManually returns true if content has a checksum that doesn't match the rest of it.
+//
+// Files without a checksum, e.g. rendered by older versions, are never considered modified.
+func IsModifiedManually(content []byte) bool {
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if strings.HasPrefix(line, checksumLinePrefix) {
+			expected := strings.TrimSpace(strings.TrimPrefix(line, checksumLinePrefix))
+			return expected != checksum(content)
+		}
+	}
+	return false
+}
+
+// checksum returns the checksum of content, the generated line and the checksum itself are ignored.
+func checksum(content []byte) string {
+	lines := strings.SplitAfter(string(StripGeneratedLine(content)), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, checksumLinePrefix) {
+			kept = append(kept, line)
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(kept, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ManualEdit is a config modified manually since it was rendered.
+type ManualEdit struct {
+	// Path of the file relative to the peers folder.
+	Path    string
+	Current []byte
+	// Rendered is nil if the config is to be removed.
+	Rendered []byte
+}
+
+// ManualEditError is returned when configs to be overwritten or removed were modified manually.
+type ManualEditError struct {
+	Edits []ManualEdit
+}
+
+func (e *ManualEditError) Error() string {
+	paths := make([]string, 0, len(e.Edits))
+	for _, edit := range e.Edits {
+		paths = append(paths, edit.Path)
+	}
+	return fmt.Sprintf("config(s) modified manually: %s", strings.Join(paths, ", "))
+}
@@ -0,0 +1,152 @@
+package rendering
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tevino/wg-make/config"
+)
+
+// ExportSchemaVersion is the version of the schema of Export, it's increased on incompatible changes only,
+// fields could be added within a version.
+const ExportSchemaVersion = 1
+
+// Roles of exported peers.
+const (
+	ExportRoleServer = "server"
+	ExportRoleClient = "client"
+)
+
+// ExportOptions controls what is exported.
+type ExportOptions struct {
+	// Secrets includes private keys and preshared keys.
+	Secrets bool
+}
+
+// Export is the resolved view of networks, which could be fed into other tools.
+type Export struct {
+	SchemaVersion int               `json:"schemaVersion" yaml:"schemaVersion"`
+	Networks      []ExportedNetwork `json:"networks" yaml:"networks"`
+}
+
+// ExportedNetwork is a network with all of its peers.
+type ExportedNetwork struct {
+	ID     string         `json:"id" yaml:"id"`
+	Subnet string         `json:"subnet" yaml:"subnet"`
+	Peers  []ExportedPeer `json:"peers" yaml:"peers"`
+}
+
+// ExportedPeer is a peer as described in the network description.
+type ExportedPeer struct {
+	ID   string `json:"id" yaml:"id"`
+	Role string `json:"role" yaml:"role"`
+	// Active is false if the peer is disabled or expired, nothing is rendered for it then.
+	Active     bool   `json:"active" yaml:"active"`
+	Address    string `json:"address" yaml:"address"`
+	Endpoint   string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	ListenPort int    `json:"listenPort,omitempty" yaml:"listenPort,omitempty"`
+	PublicKey  string `json:"publicKey" yaml:"publicKey"`
+	PrivateKey string `json:"privateKey,omitempty" yaml:"privateKey,omitempty"`
+	// AllowedIPs are the subnets routed to the peer besides its address.
+	AllowedIPs   []string `json:"allowedIPs,omitempty" yaml:"allowedIPs,omitempty"`
+	LocalSubnets []string `json:"localSubnets,omitempty" yaml:"localSubnets,omitempty"`
+	DNS          []string `json:"dns,omitempty" yaml:"dns,omitempty"`
+	OS           string   `json:"os,omitempty" yaml:"os,omitempty"`
+	Outputs      []string `json:"outputs" yaml:"outputs"`
+	ExpiresAt    string   `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+	// Config is the computed config of the peer, it's nil for inactive peers.
+	Config *ExportedConfig `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// ExportedConfig is the computed WireGuard config of a peer, see BuildPeerConfig.
+type ExportedConfig struct {
+	Address    string               `json:"address" yaml:"address"`
+	ListenPort int                  `json:"listenPort,omitempty" yaml:"listenPort,omitempty"`
+	DNS        []string             `json:"dns,omitempty" yaml:"dns,omitempty"`
+	Peers      []ExportedConfigPeer `json:"peers" yaml:"peers"`
+}
+
+// ExportedConfigPeer is a peer linked in the config of another peer.
+type ExportedConfigPeer struct {
+	ID                  string   `json:"id" yaml:"id"`
+	PublicKey           string   `json:"publicKey" yaml:"publicKey"`
+	PresharedKey        string   `json:"presharedKey,omitempty" yaml:"presharedKey,omitempty"`
+	Endpoint            string   `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	AllowedIPs          []string `json:"allowedIPs" yaml:"allowedIPs"`
+	PersistentKeepalive int      `json:"persistentKeepalive,omitempty" yaml:"persistentKeepalive,omitempty"`
+}
+
+// ExportNetworks returns the resolved view of networks at given time.
+func ExportNetworks(confs []*config.Config, now time.Time, opts ExportOptions) (*Export, error) {
+	export := &Export{SchemaVersion: ExportSchemaVersion, Networks: []ExportedNetwork{}}
+	for _, conf := range confs {
+		network, err := exportNetwork(conf, now, opts)
+		if err != nil {
+			return nil, fmt.Errorf("exporting network(%s): %w", conf.Network.ID, err)
+		}
+		export.Networks = append(export.Networks, network)
+	}
+	return export, nil
+}
+
+func exportNetwork(conf *config.Config, now time.Time, opts ExportOptions) (ExportedNetwork, error) {
+	network := ExportedNetwork{ID: conf.Network.ID, Subnet: conf.Network.Subnet, Peers: []ExportedPeer{}}
+	// Lists are split like in templates.
+	ctx := &PeerConfigTplContext{}
+	for i := range conf.Peers {
+		p := &conf.Peers[i]
+		active, err := p.IsActiveAt(now)
+		if err != nil {
+			return network, err
+		}
+		peer := ExportedPeer{
+			ID:           p.ID,
+			Role:         ExportRoleClient,
+			Active:       active,
+			Address:      p.Address,
+			Endpoint:     p.Endpoint,
+			ListenPort:   p.ListenPort,
+			PublicKey:    p.PublicKey,
+			AllowedIPs:   ctx.SplitList(p.AllowedIPs),
+			LocalSubnets: ctx.SplitList(p.LocalSubnets),
+			DNS:          ctx.SplitList(p.DNS),
+			OS:           p.OS,
+			Outputs:      p.Outputs(),
+			ExpiresAt:    p.ExpiresAt,
+		}
+		if p.IsBounceServer() {
+			peer.Role = ExportRoleServer
+		}
+		if opts.Secrets {
+			peer.PrivateKey = p.PrivateKey
+		}
+		if active {
+			wgConf, err := BuildPeerConfig(conf, p.ID, now)
+			if err != nil {
+				return network, err
+			}
+			exported := &ExportedConfig{
+				Address:    wgConf.Interface.Address,
+				ListenPort: wgConf.Interface.ListenPort,
+				DNS:        ctx.SplitList(wgConf.Interface.DNS),
+				Peers:      []ExportedConfigPeer{},
+			}
+			for _, linked := range wgConf.Peers {
+				link := ExportedConfigPeer{
+					ID:                  linked.ID,
+					PublicKey:           linked.PublicKey,
+					Endpoint:            linked.Endpoint,
+					AllowedIPs:          ctx.SplitList(linked.AllowedIPs),
+					PersistentKeepalive: linked.PersistentKeepalive,
+				}
+				if opts.Secrets {
+					link.PresharedKey = linked.PresharedKey
+				}
+				exported.Peers = append(exported.Peers, link)
+			}
+			peer.Config = exported
+		}
+		network.Peers = append(network.Peers, peer)
+	}
+	return network, nil
+}
@@ -0,0 +1,99 @@
+package rendering
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/flexi-cache/pkg/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/example"
+	"gopkg.in/yaml.v2"
+)
+
+func TestExportNetworks(t *testing.T) {
+	Convey("Export the example network", t, func() {
+		var (
+			conf *config.Config
+			err  error
+		)
+		testutil.WithTempFile(t, example.FileConfExample, func(filename string) {
+			conf, err = config.LoadConfigFromFile(filename)
+		})
+		So(err, ShouldBeNil)
+		now := time.Now()
+
+		exported, err := ExportNetworks([]*config.Config{conf}, now, ExportOptions{})
+		So(err, ShouldBeNil)
+		So(exported.SchemaVersion, ShouldEqual, ExportSchemaVersion)
+		So(exported.Networks, ShouldHaveLength, 1)
+		network := exported.Networks[0]
+		So(network.ID, ShouldEqual, "example")
+		So(network.Subnet, ShouldEqual, "192.168.25.0/24")
+		So(network.Peers, ShouldHaveLength, 3)
+
+		peers := map[string]ExportedPeer{}
+		for _, p := range network.Peers {
+			peers[p.ID] = p
+		}
+		pata := peers["Pata"]
+		So(pata.Role, ShouldEqual, ExportRoleServer)
+		So(pata.Active, ShouldBeTrue)
+		So(pata.Endpoint, ShouldEqual, "pata.example.com:49736")
+		So(pata.AllowedIPs, ShouldResemble, []string{"10.1.1.0/24"})
+		So(pata.Outputs, ShouldResemble, []string{config.OutputWGQuick})
+		So(pata.Config.ListenPort, ShouldEqual, 49736)
+		So(pata.Config.Peers, ShouldHaveLength, 2)
+
+		tento := peers["Tento"]
+		So(tento.Role, ShouldEqual, ExportRoleClient)
+		So(tento.LocalSubnets, ShouldResemble, []string{"10.1.1.0/24"})
+		So(tento.Config.Peers, ShouldResemble, []ExportedConfigPeer{{
+			ID:                  "Pata",
+			PublicKey:           "public-key-of-pata",
+			Endpoint:            "pata.example.com:49736",
+			AllowedIPs:          []string{"192.168.25.1/32", "192.168.25.0/24"},
+			PersistentKeepalive: 25,
+		}})
+
+		Convey("Secrets should be omitted unless requested", func() {
+			tentoPeer, _ := conf.GetPeerByID("Tento")
+			tentoPeer.PresharedKey = "psk-of-tento"
+			exported, err := ExportNetworks([]*config.Config{conf}, now, ExportOptions{})
+			So(err, ShouldBeNil)
+			data, err := json.Marshal(exported)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldNotContainSubstring, "private-key-of")
+			So(string(data), ShouldNotContainSubstring, "psk-of-tento")
+			So(string(data), ShouldNotContainSubstring, "privateKey")
+
+			exported, err = ExportNetworks([]*config.Config{conf}, now, ExportOptions{Secrets: true})
+			So(err, ShouldBeNil)
+			data, err = json.Marshal(exported)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, `"privateKey":"private-key-of-tento"`)
+			So(string(data), ShouldContainSubstring, `"presharedKey":"psk-of-tento"`)
+		})
+		Convey("Inactive peers should be exported without configs", func() {
+			aguPeer, _ := conf.GetPeerByID("Agu")
+			aguPeer.Disabled = true
+			exported, err := ExportNetworks([]*config.Config{conf}, now, ExportOptions{})
+			So(err, ShouldBeNil)
+			for _, p := range exported.Networks[0].Peers {
+				if p.ID == "Agu" {
+					So(p.Active, ShouldBeFalse)
+					So(p.Config, ShouldBeNil)
+				} else {
+					So(p.Config.Peers, ShouldHaveLength, 1)
+				}
+			}
+		})
+		Convey("Fields should be named the same in YAML", func() {
+			data, err := yaml.Marshal(exported)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldStartWith, "schemaVersion: 1\nnetworks:\n- id: example\n  subnet: 192.168.25.0/24\n")
+			So(string(data), ShouldContainSubstring, "    persistentKeepalive: 25\n")
+		})
+	})
+}
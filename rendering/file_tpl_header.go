@@ -0,0 +1,32 @@
+package rendering
+
+import (
+	"encoding/xml"
+	"text/template"
+)
+
+const generatedLinePrefix = "# Generated by wg-make "
+
+// fileTplHeader is the leading comments of every rendered file, the checksum is inserted after them.
+const fileTplHeader = `# This is a WireGuard configuration file for peer {{.Config.Interface.ID}} at {{.Network.ID}} network.
+{{if .GeneratedAt.IsZero -}}
+# Generated by wg-make from network description {{.DescriptionHash}}.
+{{- else -}}
+# Generated by wg-make at {{ .GeneratedAt.Format "2006-01-02 15:04:05 -0700" }}.
+{{- end}}
+# CAUTION: DO NOT modify this file manually.
+`
+
+// fileTplPrivateKeyPlaceholder is the note above private keys of peers keeping them on their devices,
+// it's called as {{template "privateKeyPlaceholder"}} after the comment marker of each format.
+const fileTplPrivateKeyPlaceholder = `{{define "privateKeyPlaceholder"}}The private key of this peer never leaves the device, replace the placeholder below with it.{{end}}`
+
+// newFileTemplate returns the template of a rendered file starting with the common header.
+func newFileTemplate(name string, text string) *template.Template {
+	return template.Must(template.New(name).Parse(fileTplPrivateKeyPlaceholder + fileTplHeader + text))
+}
+
+// newXMLFileTemplate returns the template of a rendered XML file, the common header is kept in a comment after the declaration.
+func newXMLFileTemplate(name string, text string) *template.Template {
+	return template.Must(template.New(name).Parse(fileTplPrivateKeyPlaceholder + xml.Header + "<!--\n" + fileTplHeader + "\n-->\n" + text))
+}
@@ -0,0 +1,92 @@
+package rendering
+
+var tplMobileConfig = newXMLFileTemplate("wg-network.mobileconfig", fileTplMobileConfig)
+
+const fileTplMobileConfig = `<!-- Install on iOS or macOS with the WireGuard app, the profile is unsigned, see README for signing it. -->
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>PayloadDisplayName</key>
+  <string>WireGuard {{html .Network.ID}}</string>
+  <key>PayloadType</key>
+  <string>Configuration</string>
+  <key>PayloadVersion</key>
+  <integer>1</integer>
+  <key>PayloadIdentifier</key>
+  <string>{{html (.AppleIdentifier "profile")}}</string>
+  <key>PayloadUUID</key>
+  <string>{{.AppleUUID "profile"}}</string>
+  <key>PayloadContent</key>
+  <array>
+    <dict>
+      <key>PayloadDisplayName</key>
+      <string>VPN</string>
+      <key>PayloadType</key>
+      <string>com.apple.vpn.managed</string>
+      <key>PayloadVersion</key>
+      <integer>1</integer>
+      <key>PayloadIdentifier</key>
+      <string>{{html (.AppleIdentifier "vpn")}}</string>
+      <key>PayloadUUID</key>
+      <string>{{.AppleUUID "vpn"}}</string>
+      <key>UserDefinedName</key>
+      <string>{{html .InterfaceName}}</string>
+      <key>VPNType</key>
+      <string>VPN</string>
+      <key>VPNSubType</key>
+      <string>{{.AppleVPNSubType}}</string>
+      <key>VendorConfig</key>
+      <dict>
+        <key>WgQuickConfig</key>
+        <string>{{html .WgQuickConfig}}</string>
+      </dict>
+      <key>VPN</key>
+      <dict>
+        <key>RemoteAddress</key>
+        <string>{{html .AppleRemoteAddress}}</string>
+        <key>AuthenticationMethod</key>
+        <string>Password</string>
+{{- $ssids := .AppleTrustedSSIDs}}{{$dnsMatches := .AppleLocalDNSMatches}}
+{{- if or $ssids $dnsMatches}}
+        <!-- Connect on demand unless at the trusted networks of the peer. -->
+        <key>OnDemandEnabled</key>
+        <integer>1</integer>
+        <key>OnDemandRules</key>
+        <array>
+{{- if $ssids}}
+          <dict>
+            <key>Action</key>
+            <string>Disconnect</string>
+            <key>InterfaceTypeMatch</key>
+            <string>WiFi</string>
+            <key>SSIDMatch</key>
+            <array>
+{{- range $ssids}}
+              <string>{{html .}}</string>{{end}}
+            </array>
+          </dict>
+{{- end}}
+{{- if $dnsMatches}}
+          <!-- DNS servers of the current network are in LocalSubnets. -->
+          <dict>
+            <key>Action</key>
+            <string>Disconnect</string>
+            <key>DNSServerAddressMatch</key>
+            <array>
+{{- range $dnsMatches}}
+              <string>{{.}}</string>{{end}}
+            </array>
+          </dict>
+{{- end}}
+          <dict>
+            <key>Action</key>
+            <string>Connect</string>
+          </dict>
+        </array>
+{{- end}}
+      </dict>
+    </dict>
+  </array>
+</dict>
+</plist>
+`
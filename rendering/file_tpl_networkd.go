@@ -0,0 +1,57 @@
+package rendering
+
+var (
+	tplNetdev  = newFileTemplate("wg-network.netdev", fileTplNetdev)
+	tplNetwork = newFileTemplate("wg-network.network", fileTplNetwork)
+)
+
+const fileTplNetdev = `
+[NetDev]
+Name = {{.InterfaceName}}
+Kind = wireguard
+Description = WireGuard network {{.Network.ID}}
+
+{{with .Config.Interface -}}
+[WireGuard]
+# ID = {{.ID}}
+{{- if eq .PrivateKey $.PrivateKeyPlaceholder}}
+# {{template "privateKeyPlaceholder"}}
+{{- end}}
+PrivateKey = {{.PrivateKey}}
+{{- with .ListenPort}}
+ListenPort = {{.}}{{end}}
+# Add routes to AllowedIPs of peers like wg-quick does.
+RouteTable = {{$.RouteTable}}
+{{- end}}
+{{- range .Config.Peers}}
+
+[WireGuardPeer]
+# ID = {{.ID}}
+{{- with .Endpoint}}
+Endpoint = {{.}}{{end}}
+PublicKey = {{.PublicKey}}
+{{- with .PresharedKey}}
+PresharedKey = {{.}}{{end}}
+AllowedIPs = {{.AllowedIPs}}
+{{- with .PersistentKeepalive}}
+PersistentKeepalive = {{.}}{{end}}
+{{- end}}
+`
+
+const fileTplNetwork = `
+[Match]
+Name = {{.InterfaceName}}
+
+[Network]
+Address = {{.Config.Interface.Address}}
+{{- range .DNSServers}}
+DNS = {{.}}{{end}}
+{{- range .DNSSearchDomains}}
+Domains = {{.}}{{end}}
+{{- if .Peer.IsBounceServer}}
+
+# Relay traffic of peers, masquerading it as from this peer.
+IPForward = yes
+IPMasquerade = both
+{{- end}}
+`
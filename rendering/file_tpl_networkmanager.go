@@ -0,0 +1,55 @@
+package rendering
+
+var tplNMConnection = newFileTemplate("wg-network.nmconnection", fileTplNMConnection)
+
+const fileTplNMConnection = `
+[connection]
+id={{.InterfaceName}}
+uuid={{.NMUUID}}
+type=wireguard
+interface-name={{.InterfaceName}}
+
+{{with .Config.Interface -}}
+[wireguard]
+# ID = {{.ID}}
+{{- if eq .PrivateKey $.PrivateKeyPlaceholder}}
+# {{template "privateKeyPlaceholder"}}
+{{- end}}
+private-key={{.PrivateKey}}
+private-key-flags=0
+{{- with .ListenPort}}
+listen-port={{.}}{{end}}
+# Add routes to allowed-ips of peers like wg-quick does.
+peer-routes=true
+{{- end}}
+{{- range .Config.Peers}}
+
+[wireguard-peer.{{.PublicKey}}]
+# ID = {{.ID}}
+{{- with .Endpoint}}
+endpoint={{.}}{{end}}
+{{- with .PresharedKey}}
+preshared-key={{.}}
+preshared-key-flags=0{{end}}
+allowed-ips={{$.NMList .AllowedIPs}}
+{{- with .PersistentKeepalive}}
+persistent-keepalive={{.}}{{end}}
+{{- end}}
+{{- range .NMIPSettings}}
+
+[{{.Section}}]
+method={{.Method}}
+{{- with .Address}}
+address1={{.}}{{end}}
+{{- with .DNS}}
+dns={{.}}
+# Use only these DNS servers while connected like wg-quick does.
+dns-priority=-50{{end}}
+{{- with .DNSSearch}}
+dns-search={{.}}{{end}}
+{{- end}}
+{{- if .Peer.IsBounceServer}}
+
+# NOTE: NetworkManager doesn't forward packets, enable it with sysctl to relay traffic of peers.
+{{- end}}
+`
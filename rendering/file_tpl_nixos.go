@@ -0,0 +1,62 @@
+package rendering
+
+var tplNixOS = newFileTemplate("wg-network.nix", fileTplNixOS)
+
+const fileTplNixOS = `# Import this module in configuration.nix, keys are read from files to keep them out of the Nix store.
+# Before switching, write the keys into the files below, readable by root only, e.g. with: umask 077; cat > FILE
+{{- with .Config.Interface}}
+#   {{$.NixPrivateKeyFile}}: PrivateKey of {{.ID}}{{if eq .PrivateKey $.PrivateKeyPlaceholder}}, which never leaves the device{{end}}
+{{- end}}
+{{- range .Config.Peers}}{{if .PresharedKey}}
+#   {{$.NixPresharedKeyFile .ID}}: PresharedKey with {{.ID}}
+{{- end}}{{end}}
+
+{ ... }:
+
+{
+{{- with .Config.Interface}}
+  networking.wireguard.interfaces.{{$.NixString $.InterfaceName}} = {
+    # ID = {{.ID}}
+    ips = [ {{$.NixString .Address}} ];
+{{- with .ListenPort}}
+    listenPort = {{.}};{{end}}
+    privateKeyFile = {{$.NixString $.NixPrivateKeyFile}};
+    peers = [
+{{- end}}
+{{- range .Config.Peers}}
+      {
+        # ID = {{.ID}}
+        publicKey = {{$.NixString .PublicKey}};
+{{- if .PresharedKey}}
+        presharedKeyFile = {{$.NixString ($.NixPresharedKeyFile .ID)}};{{end}}
+        allowedIPs = [{{range $.SplitList .AllowedIPs}} {{$.NixString .}}{{end}} ];
+{{- with .Endpoint}}
+        endpoint = {{$.NixString .}};{{end}}
+{{- with .PersistentKeepalive}}
+        persistentKeepalive = {{.}};{{end}}
+      }
+{{- end}}
+    ];
+  };
+{{- with .Config.Interface.ListenPort}}
+
+  networking.firewall.allowedUDPPorts = [ {{.}} ];
+{{- end}}
+{{- if .Peer.IsBounceServer}}
+
+  # Relay traffic of peers, masquerading it as from this peer.
+  boot.kernel.sysctl = {
+    "net.ipv4.ip_forward" = 1;
+    "net.ipv6.conf.all.forwarding" = 1;
+  };
+  networking.nat = {
+    enable = true;
+{{- if .IsIPv6 .Network.Subnet}}
+    enableIPv6 = true;
+{{- end}}
+    externalInterface = {{.NixString .Peer.PublicInterface}};
+    internalInterfaces = [ {{.NixString .InterfaceName}} ];
+  };
+{{- end}}
+}
+`
@@ -0,0 +1,84 @@
+package rendering
+
+var (
+	tplUCINetwork  = newFileTemplate("wg-network.network.uci", fileTplUCINetwork)
+	tplUCIFirewall = newFileTemplate("wg-network.firewall.uci", fileTplUCIFirewall)
+)
+
+const fileTplUCINetwork = `# Merge into /etc/config/network, replacing the sections of {{.UCIInterface}} rendered before, then run: service network reload
+{{with .Config.Interface}}
+config interface '{{$.UCIInterface}}'
+	# ID = {{.ID}}
+{{- if eq .PrivateKey $.PrivateKeyPlaceholder}}
+	# {{template "privateKeyPlaceholder"}}
+{{- end}}
+	option proto 'wireguard'
+	option private_key {{$.ShellQuote .PrivateKey}}
+{{- with .ListenPort}}
+	option listen_port '{{.}}'{{end}}
+	list addresses {{$.ShellQuote .Address}}
+{{- range $.DNSServers}}
+	list dns {{$.ShellQuote .}}{{end}}
+{{- range $.DNSSearchDomains}}
+	list dns_search {{$.ShellQuote .}}{{end}}
+{{- end}}
+{{- range .Config.Peers}}
+
+config wireguard_{{$.UCIInterface}}
+	option description {{$.ShellQuote .ID}}
+	option public_key {{$.ShellQuote .PublicKey}}
+{{- with .PresharedKey}}
+	option preshared_key {{$.ShellQuote .}}{{end}}
+{{- with .Endpoint}}
+	option endpoint_host {{$.ShellQuote ($.EndpointHost .)}}
+	option endpoint_port {{$.ShellQuote ($.EndpointPort .)}}{{end}}
+{{- with .PersistentKeepalive}}
+	option persistent_keepalive '{{.}}'{{end}}
+	# Add routes to allowed_ips like wg-quick does.
+	option route_allowed_ips '1'
+{{- range $.SplitList .AllowedIPs}}
+	list allowed_ips {{$.ShellQuote .}}{{end}}
+{{- end}}
+`
+
+const fileTplUCIFirewall = `# Merge into /etc/config/firewall, replacing the sections of {{.UCIInterface}} rendered before, then run: service firewall reload
+
+config zone
+	option name '{{.UCIInterface}}'
+	list network '{{.UCIInterface}}'
+	option input 'ACCEPT'
+	option output 'ACCEPT'
+{{- if .Peer.IsBounceServer}}
+	# Relay traffic between peers.
+	option forward 'ACCEPT'
+{{- else}}
+	option forward 'REJECT'
+{{- end}}
+{{- with .Config.Interface.ListenPort}}
+
+config rule
+	option name 'Allow-WireGuard-{{$.UCIInterface}}'
+	option src 'wan'
+	option proto 'udp'
+	option dest_port '{{.}}'
+	option target 'ACCEPT'
+{{- end}}
+{{- if .Peer.AllowedIPs}}
+
+# Route traffic between the LAN and peers.
+config forwarding
+	option src 'lan'
+	option dest '{{.UCIInterface}}'
+
+config forwarding
+	option src '{{.UCIInterface}}'
+	option dest 'lan'
+{{- end}}
+{{- if .Peer.IsBounceServer}}
+
+# Relay traffic of peers to the Internet, masqueraded by the wan zone.
+config forwarding
+	option src '{{.UCIInterface}}'
+	option dest 'wan'
+{{- end}}
+`
@@ -0,0 +1,108 @@
+package rendering
+
+var tplOPNsense = newXMLFileTemplate("wg-network.opnsense.xml", fileTplOPNsense)
+
+const fileTplOPNsense = `<!-- Merge into /conf/config.xml of OPNsense, replacing the sections rendered before, then reboot or reload all services. -->
+<opnsense>
+  <OPNsense>
+    <wireguard>
+      <general version="0.0.1">
+        <enabled>1</enabled>
+      </general>
+      <server version="1.0.0">
+        <servers>
+{{- with .Config.Interface}}
+          <!-- ID = {{html .ID}} -->
+{{- if eq .PrivateKey $.PrivateKeyPlaceholder}}
+          <!-- {{template "privateKeyPlaceholder"}} -->
+{{- end}}
+          <server uuid="{{$.OPNsenseUUID ""}}">
+            <enabled>1</enabled>
+            <name>{{html $.InterfaceName}}</name>
+            <instance>{{$.OPNsenseInstance}}</instance>
+            <pubkey>{{html $.Peer.PublicKey}}</pubkey>
+            <privkey>{{html .PrivateKey}}</privkey>
+            <port>{{with .ListenPort}}{{.}}{{end}}</port>
+            <dns>{{html .DNS}}</dns>
+            <tunneladdress>{{html .Address}}</tunneladdress>
+            <disableroutes>0</disableroutes>
+            <peers>{{$.OPNsensePeerUUIDs}}</peers>
+          </server>
+{{- end}}
+        </servers>
+      </server>
+      <client version="1.0.0">
+        <clients>
+{{- range .Config.Peers}}
+          <client uuid="{{$.OPNsenseUUID .ID}}">
+            <enabled>1</enabled>
+            <name>{{html .ID}}</name>
+            <pubkey>{{html .PublicKey}}</pubkey>
+            <psk>{{html .PresharedKey}}</psk>
+            <tunneladdress>{{html .AllowedIPs}}</tunneladdress>
+            <serveraddress>{{with .Endpoint}}{{html ($.EndpointHost .)}}{{end}}</serveraddress>
+            <serverport>{{with .Endpoint}}{{$.EndpointPort .}}{{end}}</serverport>
+            <keepalive>{{with .PersistentKeepalive}}{{.}}{{end}}</keepalive>
+          </client>
+{{- end}}
+        </clients>
+      </client>
+    </wireguard>
+  </OPNsense>
+{{- if or .Config.Interface.ListenPort .Peer.IsBounceServer}}
+  <filter>
+{{- with .Config.Interface.ListenPort}}
+    <rule>
+      <type>pass</type>
+      <interface>wan</interface>
+      <ipprotocol>inet46</ipprotocol>
+      <protocol>udp</protocol>
+      <source>
+        <any>1</any>
+      </source>
+      <destination>
+        <network>wanip</network>
+        <port>{{.}}</port>
+      </destination>
+      <descr>wg-make:{{html $.Network.ID}} WireGuard</descr>
+    </rule>
+{{- end}}
+{{- if .Peer.IsBounceServer}}
+    <!-- Relay traffic of peers like the FORWARD rules of wg-quick. -->
+    <rule>
+      <type>pass</type>
+      <interface>wireguard</interface>
+      <ipprotocol>inet46</ipprotocol>
+      <source>
+        <any>1</any>
+      </source>
+      <destination>
+        <any>1</any>
+      </destination>
+      <descr>wg-make:{{html .Network.ID}} relay</descr>
+    </rule>
+{{- end}}
+  </filter>
+{{- end}}
+{{- if .Peer.IsBounceServer}}
+  <nat>
+    <outbound>
+      <mode>hybrid</mode>
+      <!-- Masquerade traffic of peers like the MASQUERADE rule of wg-quick. -->
+      <rule>
+        <interface>{{html .Peer.PublicInterface}}</interface>
+        <ipprotocol>{{if .IsIPv6 .Network.Subnet}}inet6{{else}}inet{{end}}</ipprotocol>
+        <source>
+          <network>{{html .Network.Subnet}}</network>
+        </source>
+        <destination>
+          <any>1</any>
+        </destination>
+        <target/>
+        <descr>wg-make:{{html .Network.ID}} masquerade</descr>
+      </rule>
+    </outbound>
+  </nat>
+{{- end}}
+</opnsense>
+`
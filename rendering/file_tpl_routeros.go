@@ -0,0 +1,43 @@
+package rendering
+
+var tplRouterOS = newFileTemplate("wg-network.rsc", fileTplRouterOS)
+
+const fileTplRouterOS = `# Import on RouterOS 7 with: /import file-name=wg-{{.Network.ID}}.rsc
+# Entries commented with {{.RouterOSTag}} are removed before being added again, so the script could be imported after every change.
+
+/ip firewall nat remove [find comment={{.RouterOSTag}}]
+/ipv6 firewall nat remove [find comment={{.RouterOSTag}}]
+/ip route remove [find comment={{.RouterOSTag}}]
+/ipv6 route remove [find comment={{.RouterOSTag}}]
+/ip address remove [find comment={{.RouterOSTag}}]
+/ipv6 address remove [find comment={{.RouterOSTag}}]
+/interface wireguard peers remove [find comment={{.RouterOSTag}}]
+/interface wireguard remove [find comment={{.RouterOSTag}}]
+{{with .Config.Interface}}
+# ID = {{.ID}}
+{{- if eq .PrivateKey $.PrivateKeyPlaceholder}}
+# {{template "privateKeyPlaceholder"}}
+{{- end}}
+/interface wireguard add name={{$.InterfaceName}} comment={{$.RouterOSTag}} private-key={{$.RouterOSQuote .PrivateKey}}
+{{- with .ListenPort}} listen-port={{.}}{{end}}
+{{- end}}
+{{range .Config.Peers}}
+# ID = {{.ID}}
+/interface wireguard peers add interface={{$.InterfaceName}} comment={{$.RouterOSTag}} public-key={{$.RouterOSQuote .PublicKey}}
+{{- with .PresharedKey}} preshared-key={{$.RouterOSQuote .}}{{end}}
+{{- with .Endpoint}} endpoint-address={{$.EndpointHost .}} endpoint-port={{$.EndpointPort .}}{{end}}
+{{- with .PersistentKeepalive}} persistent-keepalive={{.}}s{{end}} allowed-address={{.AllowedIPs}}
+{{- end}}
+
+{{with .Config.Interface.Address -}}
+{{$.RouterOSMenu .}} address add interface={{$.InterfaceName}} comment={{$.RouterOSTag}} address={{.}}
+{{- end}}
+{{- range .Routes}}
+{{$.RouterOSMenu .}} route add gateway={{$.InterfaceName}} comment={{$.RouterOSTag}} dst-address={{.}}
+{{- end}}
+{{- if .Peer.IsBounceServer}}
+
+# Relay traffic of peers to the Internet.
+{{$.RouterOSMenu .Network.Subnet}} firewall nat add chain=srcnat action=masquerade comment={{.RouterOSTag}} src-address={{.Network.Subnet}} out-interface={{.Peer.PublicInterface}}
+{{- end}}
+`
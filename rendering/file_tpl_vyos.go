@@ -0,0 +1,52 @@
+package rendering
+
+var tplVyOS = newFileTemplate("wg-network.vyos", fileTplVyOS)
+
+const fileTplVyOS = `# Paste into the configuration mode of VyOS 1.4 or later, then run: commit; save
+# The interface and its routes are deleted before being set again, so the commands could be pasted after every change.
+{{with .Config.Interface}}
+delete interfaces wireguard {{$.InterfaceName}}
+set interfaces wireguard {{$.InterfaceName}} description 'wg-make:{{$.Network.ID}}'
+# ID = {{.ID}}
+{{- if eq .PrivateKey $.PrivateKeyPlaceholder}}
+# {{template "privateKeyPlaceholder"}}
+{{- end}}
+set interfaces wireguard {{$.InterfaceName}} private-key {{$.ShellQuote .PrivateKey}}
+set interfaces wireguard {{$.InterfaceName}} address {{$.ShellQuote .Address}}
+{{- with .ListenPort}}
+set interfaces wireguard {{$.InterfaceName}} port '{{.}}'{{end}}
+{{- end}}
+{{- range .Config.Peers}}
+{{- $peer := printf "set interfaces wireguard %s peer %s" $.InterfaceName ($.VyOSPeerName .ID)}}
+
+# ID = {{.ID}}
+{{$peer}} public-key {{$.ShellQuote .PublicKey}}
+{{- with .PresharedKey}}
+{{$peer}} preshared-key {{$.ShellQuote .}}{{end}}
+{{- range $.SplitList .AllowedIPs}}
+{{$peer}} allowed-ips {{$.ShellQuote .}}{{end}}
+{{- with .Endpoint}}
+{{$peer}} {{if $.IsIP ($.EndpointHost .)}}address{{else}}host-name{{end}} {{$.ShellQuote ($.EndpointHost .)}}
+{{$peer}} port {{$.ShellQuote ($.EndpointPort .)}}{{end}}
+{{- with .PersistentKeepalive}}
+{{$peer}} persistent-keepalive '{{.}}'{{end}}
+{{- end}}
+
+# Route AllowedIPs of peers to the interface like wg-quick does, existing routes to the interface are deleted first.
+
+for prefix in $(cli-shell-api listNodes protocols static route | tr -d "'"); do cli-shell-api exists protocols static route "$prefix" interface {{.InterfaceName}} && delete protocols static route "$prefix"; done
+for prefix in $(cli-shell-api listNodes protocols static route6 | tr -d "'"); do cli-shell-api exists protocols static route6 "$prefix" interface {{.InterfaceName}} && delete protocols static route6 "$prefix"; done
+{{- range .Routes}}
+set protocols static {{if $.IsIPv6 .}}route6{{else}}route{{end}} {{.}} interface {{$.InterfaceName}}
+{{- end}}
+{{- if .Peer.IsBounceServer}}
+{{- $nat := .VyOSNATRule}}
+
+# Relay traffic of peers to the Internet.
+delete {{$nat}}
+set {{$nat}} description 'wg-make:{{.Network.ID}}'
+set {{$nat}} outbound-interface name {{.ShellQuote .Peer.PublicInterface}}
+set {{$nat}} source {{if .IsIPv6 .Network.Subnet}}prefix{{else}}address{{end}} {{.ShellQuote .Network.Subnet}}
+set {{$nat}} translation address 'masquerade'
+{{- end}}
+`
@@ -0,0 +1,162 @@
+package rendering
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/tevino/log"
+)
+
+// ManifestFilename is the name of the manifest within the peers folder.
+const ManifestFilename = ".wg-make-manifest.json"
+
+// Manifest records the files rendered for each network in the peers folder.
+type Manifest struct {
+	// Files of each network, mapping paths relative to the peers folder to hashes of the contents.
+	Networks map[string]map[string]string `json:"networks"`
+}
+
+// LoadManifest reads the manifest in dirPeers, an empty one is returned if it does not exist.
+func LoadManifest(dirPeers string) (*Manifest, error) {
+	m := &Manifest{Networks: map[string]map[string]string{}}
+	manifestPath := path.Join(dirPeers, ManifestFilename)
+	data, err := ioutil.ReadFile(manifestPath)
+	if os.IsNotExist(err) {
+		return m, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading manifest(%s): %w", manifestPath, err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing manifest(%s): %w", manifestPath, err)
+	}
+	if m.Networks == nil {
+		m.Networks = map[string]map[string]string{}
+	}
+	return m, nil
+}
+
+// Save writes the manifest into dirPeers.
+func (m *Manifest) Save(dirPeers string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	manifestPath := path.Join(dirPeers, ManifestFilename)
+	if err := writeFiles([]pendingFile{{path: manifestPath, content: append(data, '\n'), mode: fileModeSensitive}}); err != nil {
+		return fmt.Errorf("writing manifest(%s): %w", manifestPath, err)
+	}
+	return nil
+}
+
+// StaleFiles returns the sorted paths of files recorded for the network but not among files.
+func (m *Manifest) StaleFiles(networkID string, files []RenderedFile) []string {
+	current := map[string]bool{}
+	for _, f := range files {
+		current[f.Path] = true
+	}
+	stale := []string{}
+	for p := range m.Networks[networkID] {
+		if !current[p] {
+			stale = append(stale, p)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// StaleNetworks returns the sorted IDs of networks recorded but not among networkIDs.
+func (m *Manifest) StaleNetworks(networkIDs []string) []string {
+	current := map[string]bool{}
+	for _, id := range networkIDs {
+		current[id] = true
+	}
+	stale := []string{}
+	for id := range m.Networks {
+		if !current[id] {
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// PruneNetworks removes the files of networks that are no longer among networkIDs.
+//
+// A *ManualEditError is returned without removing anything if any of the files was modified manually, unless force is set.
+func PruneNetworks(dirPeers string, networkIDs []string, force bool) error {
+	m, err := LoadManifest(dirPeers)
+	if err != nil {
+		return err
+	}
+	stale := m.StaleNetworks(networkIDs)
+	if len(stale) == 0 {
+		return nil
+	}
+	stalePaths := []string{}
+	for _, networkID := range stale {
+		stalePaths = append(stalePaths, m.StaleFiles(networkID, nil)...)
+	}
+	if edits := checkRemovals(dirPeers, stalePaths, force); len(edits) > 0 {
+		return &ManualEditError{Edits: edits}
+	}
+	for _, networkID := range stale {
+		log.Infof("Pruning configs of removed network: %s\n", networkID)
+		if err := removeFiles(dirPeers, m.StaleFiles(networkID, nil)); err != nil {
+			return err
+		}
+		delete(m.Networks, networkID)
+	}
+	return m.Save(dirPeers)
+}
+
+// checkRemovals returns the files at paths relative to dirPeers which were modified manually and would be lost by removing them,
+// they are only logged if force is set.
+func checkRemovals(dirPeers string, paths []string, force bool) []ManualEdit {
+	edits := []ManualEdit{}
+	for _, p := range paths {
+		filePath := path.Join(dirPeers, p)
+		current, err := ioutil.ReadFile(filePath)
+		if err != nil || !IsModifiedManually(current) {
+			continue
+		}
+		if force {
+			log.Warnf("Removing config modified manually: %s\n", filePath)
+			continue
+		}
+		edits = append(edits, ManualEdit{Path: p, Current: current})
+	}
+	return edits
+}
+
+// removeFiles removes the files at paths relative to dirPeers along with the emptied folders of peers,
+// QR code images beside wg-quick configs are removed as well.
+func removeFiles(dirPeers string, paths []string) error {
+	for _, p := range paths {
+		filePath := path.Join(dirPeers, p)
+		log.Infof("Removing stale config: %s\n", filePath)
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing stale config(%s): %w", filePath, err)
+		}
+		if strings.HasSuffix(filePath, ".conf") {
+			if err := removeQRImages(filePath); err != nil {
+				return err
+			}
+		}
+		// Folders of peers are only removed when they become empty.
+		os.Remove(path.Dir(filePath))
+	}
+	return nil
+}
+
+// contentHash returns the hash of a rendered config, the generated line is ignored.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(StripGeneratedLine(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,117 @@
+package rendering
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/config/wireguard"
+)
+
+// forwardingHookComments are the comments of the hooks returned by forwardingHooks in rendered configs, by index.
+var forwardingHookComments = []struct{ up, down string }{
+	{up: "Backup settings then enable packet forwarding in kernel-level.", down: "Restore settings then remove the backup."},
+	{up: "Enable/disable packet forwarding after the interface is up/down"},
+}
+
+// forwardingHooks returns the hooks enabling packet forwarding on Linux bounce servers.
+func forwardingHooks(publicInterface string) (postUps, postDowns []string) {
+	// Enable/disable NAT and forwarding after the interface is up/down.
+	rules := func(action string) string {
+		return strings.Join([]string{
+			"iptables -t nat " + action + " POSTROUTING -o " + publicInterface + " -j MASQUERADE",
+			"ip6tables -t nat " + action + " POSTROUTING -o " + publicInterface + " -j MASQUERADE",
+			"iptables " + action + " FORWARD -i %i -j ACCEPT",
+			"iptables " + action + " FORWARD -o %i -j ACCEPT;",
+		}, "; ")
+	}
+	postUps = []string{
+		// Backup settings then enable packet forwarding in kernel-level.
+		`sysctl "net.ipv4.ip_forward" >> /tmp/.sysctl-save; sysctl -w "net.ipv4.ip_forward=1"`,
+		rules("-A"),
+	}
+	postDowns = []string{
+		// Restore settings then remove the backup.
+		"sysctl -p /tmp/.sysctl-save && rm -f /tmp/.sysctl-save",
+		rules("-D"),
+	}
+	return postUps, postDowns
+}
+
+// BuildPeerConfig computes the WireGuard configuration of a peer within a network at given time.
+//
+// Everything is resolved in the returned config, e.g. AllowedIPs, hooks and keepalive,
+// serializers only have to format it. Disabled and expired peers are left out and peers are sorted by IDs.
+// The private key of public-key-only peers is PrivateKeyPlaceholder.
+func BuildPeerConfig(conf *config.Config, peerID string, now time.Time) (wgConf *wireguard.Config, err error) {
+	// Invalid addresses cause panics deep in computing AllowedIPs.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("building config for Peer(%s): %v", peerID, r)
+		}
+	}()
+	target, ok := conf.GetPeerByID(peerID)
+	if !ok {
+		return nil, fmt.Errorf("peer(%s) not found", peerID)
+	}
+	// Expired peers are left out of the network.
+	activePeers, err := conf.ActivePeers(now)
+	if err != nil {
+		return nil, err
+	}
+
+	wgConf = &wireguard.Config{Interface: buildInterface(target)}
+	for i := range activePeers {
+		p := &activePeers[i]
+		if p.ID == peerID || !target.IsLinkedTo(p) {
+			continue
+		}
+		wgConf.Peers = append(wgConf.Peers, buildPeer(&conf.Network, target, p))
+	}
+	// Peers are sorted so the config doesn't change with the order of them in the description.
+	sort.SliceStable(wgConf.Peers, func(i, j int) bool { return wgConf.Peers[i].ID < wgConf.Peers[j].ID })
+	return wgConf, nil
+}
+
+func buildInterface(target *config.Peer) wireguard.Interface {
+	iface := wireguard.Interface{
+		ID:         target.ID,
+		PrivateKey: target.PrivateKey,
+		Address:    target.Address,
+		ListenPort: target.ListenPort,
+		DNS:        target.DNS,
+	}
+	if target.IsPublicKeyOnly() {
+		iface.PrivateKey = PrivateKeyPlaceholder
+	}
+	if target.IsBounceServer() && target.IsLinux() {
+		iface.PostUps, iface.PostDowns = forwardingHooks(target.PublicInterface)
+	}
+	return iface
+}
+
+// buildPeer returns the Peer section of p in the config of target.
+func buildPeer(network *config.Network, target *config.Peer, p *config.Peer) wireguard.Peer {
+	allowedIPs := []string{}
+	if ips := p.AllowedIPsForPeer(target); ips != "" {
+		allowedIPs = append(allowedIPs, ips)
+	}
+	// Clients reach the rest of the network through bounce servers.
+	if !target.IsBounceServer() {
+		allowedIPs = append(allowedIPs, network.Subnet)
+	}
+	peer := wireguard.Peer{
+		ID:           p.ID,
+		Endpoint:     p.Endpoint,
+		PublicKey:    p.PublicKey,
+		PresharedKey: target.PresharedKeyWith(p),
+		AllowedIPs:   strings.Join(allowedIPs, ","),
+	}
+	// Keepalive is only needed towards peers that can be reached.
+	if p.Endpoint != "" {
+		peer.PersistentKeepalive = target.PersistentKeepalive
+	}
+	return peer
+}
@@ -0,0 +1,95 @@
+package rendering
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flexi-cache/pkg/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/config/wireguard"
+	"github.com/tevino/wg-make/example"
+)
+
+func TestBuildPeerConfig(t *testing.T) {
+	Convey("Build configs of the example network", t, func() {
+		var (
+			conf *config.Config
+			err  error
+		)
+		testutil.WithTempFile(t, example.FileConfExample, func(filename string) {
+			conf, err = config.LoadConfigFromFile(filename)
+		})
+		So(err, ShouldBeNil)
+		now := time.Now()
+
+		Convey("Interface of a client should be resolved", func() {
+			wgConf, err := BuildPeerConfig(conf, "Tento", now)
+			So(err, ShouldBeNil)
+			So(wgConf.Interface.ID, ShouldEqual, "Tento")
+			So(wgConf.Interface.PrivateKey, ShouldEqual, "private-key-of-tento")
+			So(wgConf.Interface.Address, ShouldEqual, "192.168.25.55/32")
+			So(wgConf.Interface.PostUps, ShouldBeEmpty)
+			So(wgConf.Interface.PostDowns, ShouldBeEmpty)
+		})
+		Convey("Clients should only have bounce servers routing the whole subnet", func() {
+			wgConf, err := BuildPeerConfig(conf, "Tento", now)
+			So(err, ShouldBeNil)
+			So(len(wgConf.Peers), ShouldEqual, 1)
+			pata := wgConf.Peers[0]
+			So(pata.ID, ShouldEqual, "Pata")
+			So(pata.Endpoint, ShouldEqual, "pata.example.com:49736")
+			So(pata.AllowedIPs, ShouldEqual, "192.168.25.1/32,"+conf.Network.Subnet)
+			So(pata.PersistentKeepalive, ShouldEqual, 25)
+		})
+		Convey("Bounce servers should have sorted peers without keepalive", func() {
+			wgConf, err := BuildPeerConfig(conf, "Pata", now)
+			So(err, ShouldBeNil)
+			So(len(wgConf.Peers), ShouldEqual, 2)
+			So(wgConf.Peers[0].ID, ShouldEqual, "Agu")
+			So(wgConf.Peers[1].ID, ShouldEqual, "Tento")
+			So(wgConf.Peers[1].AllowedIPs, ShouldEqual, "192.168.25.55/32")
+			for _, p := range wgConf.Peers {
+				So(p.PersistentKeepalive, ShouldEqual, 0)
+			}
+		})
+		Convey("Linux bounce servers should have forwarding hooks", func() {
+			pata, _ := conf.GetPeerByID("Pata")
+			wgConf, err := BuildPeerConfig(conf, "Pata", now)
+			So(err, ShouldBeNil)
+			So(len(wgConf.Interface.PostUps), ShouldEqual, 2)
+			So(len(wgConf.Interface.PostDowns), ShouldEqual, 2)
+			So(wgConf.Interface.PostUps[1], ShouldContainSubstring, "-o "+pata.PublicInterface+" -j MASQUERADE")
+
+			pata.OS = ""
+			wgConf, err = BuildPeerConfig(conf, "Pata", now)
+			So(err, ShouldBeNil)
+			So(wgConf.Interface.PostUps, ShouldBeEmpty)
+		})
+		Convey("Public-key-only peers should have the placeholder", func() {
+			agu, _ := conf.GetPeerByID("Agu")
+			agu.PrivateKey = ""
+			wgConf, err := BuildPeerConfig(conf, "Agu", now)
+			So(err, ShouldBeNil)
+			So(wgConf.Interface.PrivateKey, ShouldEqual, PrivateKeyPlaceholder)
+		})
+		Convey("Rendered configs should be parsed back to the same config", func() {
+			tento, _ := conf.GetPeerByID("Tento")
+			tento.DNS = "192.168.25.1,corp.example.com"
+			for _, id := range []string{"Pata", "Tento"} {
+				wgConf, err := BuildPeerConfig(conf, id, now)
+				So(err, ShouldBeNil)
+				f, err := wireguard.Parse([]byte(renderWgQuick(conf, id, now, Options{})))
+				So(err, ShouldBeNil)
+				parsed, unknown, err := f.Config()
+				So(err, ShouldBeNil)
+				So(unknown, ShouldBeEmpty)
+				So(parsed, ShouldResemble, wgConf)
+			}
+		})
+		Convey("Unknown peers should fail", func() {
+			_, err := BuildPeerConfig(conf, "Nobody", now)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
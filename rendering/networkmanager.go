@@ -0,0 +1,62 @@
+package rendering
+
+import (
+	"net"
+	"strings"
+)
+
+// NMIPSection is the ipv4 or ipv6 section of a NetworkManager keyfile.
+type NMIPSection struct {
+	Section string
+	Method  string
+	Address string
+	// DNS and DNSSearch are lists in the format of keyfiles, e.g. 192.0.2.1;192.0.2.2;
+	DNS       string
+	DNSSearch string
+}
+
+// NMIPSettings returns the ipv4 and ipv6 sections of the peer in NetworkManager keyfiles.
+//
+// DNS settings are only allowed in the section of the address of the peer, servers of the other IP version are left out.
+func (c *PeerConfigTplContext) NMIPSettings() []NMIPSection {
+	v4 := NMIPSection{Section: "ipv4", Method: "disabled"}
+	v6 := NMIPSection{Section: "ipv6", Method: "disabled"}
+	address := c.Config.Interface.Address
+	active, isV4 := &v4, true
+	if ip, _, err := net.ParseCIDR(address); err == nil && ip.To4() == nil {
+		active, isV4 = &v6, false
+	}
+	servers, domains := splitDNS(c.Config.Interface.DNS)
+	sameVersion := []string{}
+	for _, server := range servers {
+		if (net.ParseIP(server).To4() != nil) == isV4 {
+			sameVersion = append(sameVersion, server)
+		}
+	}
+	active.Method = "manual"
+	active.Address = address
+	active.DNS = nmList(sameVersion)
+	active.DNSSearch = nmList(domains)
+	return []NMIPSection{v4, v6}
+}
+
+// NMList returns a comma-separated list in the format of NetworkManager keyfiles.
+func (c *PeerConfigTplContext) NMList(list string) string {
+	return nmList(strings.Split(list, ","))
+}
+
+func nmList(items []string) string {
+	var b strings.Builder
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			b.WriteString(item + ";")
+		}
+	}
+	return b.String()
+}
+
+// NMUUID returns the UUID of the connection, which is derived from the IDs of the network and the peer
+// so NetworkManager recognizes the same connection between renderings.
+func (c *PeerConfigTplContext) NMUUID() string {
+	return nameUUID(c.Network.ID + ":" + c.Config.Interface.ID)
+}
@@ -0,0 +1,24 @@
+package rendering
+
+import (
+	"path"
+	"strings"
+)
+
+// nixKeyDir is the folder on NixOS peers keeping keys out of the Nix store.
+const nixKeyDir = "/etc/wireguard/keys"
+
+// NixString returns value as a string in Nix expressions.
+func (c *PeerConfigTplContext) NixString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`).Replace(value) + `"`
+}
+
+// NixPrivateKeyFile returns the path of the file of the private key on the peer.
+func (c *PeerConfigTplContext) NixPrivateKeyFile() string {
+	return path.Join(nixKeyDir, c.InterfaceName()+".key")
+}
+
+// NixPresharedKeyFile returns the path of the file of the preshared key with the peer of name.
+func (c *PeerConfigTplContext) NixPresharedKeyFile(name string) string {
+	return path.Join(nixKeyDir, c.InterfaceName()+"-"+name+".psk")
+}
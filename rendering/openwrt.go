@@ -0,0 +1,10 @@
+package rendering
+
+import "regexp"
+
+var reUCIInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// UCIInterface returns the name of the interface in UCI configs, which only allows letters, digits and underscores.
+func (c *PeerConfigTplContext) UCIInterface() string {
+	return reUCIInvalid.ReplaceAllString(c.InterfaceName(), "_")
+}
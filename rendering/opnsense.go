@@ -0,0 +1,35 @@
+package rendering
+
+import (
+	"strconv"
+	"strings"
+)
+
+// OPNsenseUUID returns the UUID of the local instance if name is empty, or the UUID of the peer of name otherwise.
+func (c *PeerConfigTplContext) OPNsenseUUID(name string) string {
+	return nameUUID("opnsense:" + c.Network.ID + ":" + c.Config.Interface.ID + ":" + name)
+}
+
+// OPNsensePeerUUIDs returns the comma-separated UUIDs of all peers linked to the local instance.
+func (c *PeerConfigTplContext) OPNsensePeerUUIDs() string {
+	uuids := make([]string, 0, len(c.Config.Peers))
+	for _, p := range c.Config.Peers {
+		uuids = append(uuids, c.OPNsenseUUID(p.ID))
+	}
+	return strings.Join(uuids, ",")
+}
+
+// OPNsenseInstance returns the number of the local instance, which is N of the device wgN.
+//
+// It's taken from InterfaceName of the peer if it's like wg1, or 0 otherwise.
+func (c *PeerConfigTplContext) OPNsenseInstance() int {
+	name := c.InterfaceName()
+	if !strings.HasPrefix(name, "wg") {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(name, "wg"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,171 @@
+package rendering
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+	"github.com/tevino/wg-make/config"
+)
+
+// MaxScannableQRVersion is the largest version of QR codes which could still be scanned reliably from screens by phones,
+// a version 20 code has 97x97 modules.
+const MaxScannableQRVersion = 20
+
+// qrPNGModuleSize is the size of each module of QR codes in PNG images, in pixels.
+const qrPNGModuleSize = 8
+
+// qrImageExts are the extensions of QR code images written beside wg-quick configs.
+var qrImageExts = []string{".png", ".svg"}
+
+// RenderQRContent returns the wg-quick config of the peer to be encoded in QR codes.
+//
+// Comments and blank lines are left out to keep the code small.
+func RenderQRContent(conf *config.Config, peerID string, now time.Time) (string, error) {
+	peer, ok := conf.GetPeerByID(peerID)
+	if !ok {
+		return "", fmt.Errorf("peer(%s) not found", peerID)
+	}
+	if peer.IsPublicKeyOnly() {
+		return "", fmt.Errorf("the private key of Peer(%s) is not in the network description", peerID)
+	}
+	// The header is not rendered into QR codes.
+	ctx, err := newPeerConfigTplContext(conf, peerID, now, Options{}, "")
+	if err != nil {
+		return "", err
+	}
+	wgQuickConfig, err := ctx.WgQuickConfig()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(wgQuickConfig, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String(), nil
+}
+
+// QRCode is a QR code of a config.
+type QRCode struct {
+	code *qrcode.QRCode
+}
+
+// NewQRCode returns the QR code of content, an error is returned if content is too large for any QR code.
+func NewQRCode(content string) (*QRCode, error) {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("encoding QR code: %w", err)
+	}
+	return &QRCode{code: code}, nil
+}
+
+// Version returns the version of the QR code, which decides the number of modules, see MaxScannableQRVersion.
+func (q *QRCode) Version() int {
+	return q.code.VersionNumber
+}
+
+// Terminal returns the QR code drawn with ANSI colors, each character is the upper and lower half of two modules.
+func (q *QRCode) Terminal() string {
+	// Black or white in the foreground or the background.
+	color := func(dark bool, foreground bool) string {
+		code := 47
+		if dark {
+			code = 40
+		}
+		if foreground {
+			code -= 10
+		}
+		return fmt.Sprintf("\x1b[%dm", code)
+	}
+	bitmap := q.code.Bitmap()
+	var b strings.Builder
+	for y := 0; y < len(bitmap); y += 2 {
+		last := ""
+		for x := range bitmap[y] {
+			lower := false
+			if y+1 < len(bitmap) {
+				lower = bitmap[y+1][x]
+			}
+			// Colors are only set when they change.
+			if colors := color(bitmap[y][x], true) + color(lower, false); colors != last {
+				b.WriteString(colors)
+				last = colors
+			}
+			b.WriteString("▀")
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.String()
+}
+
+// PNG returns the QR code as a PNG image.
+func (q *QRCode) PNG() ([]byte, error) {
+	// A negative size is the size of modules.
+	return q.code.PNG(-qrPNGModuleSize)
+}
+
+// SVG returns the QR code as an SVG image, each module is a unit of the view box.
+func (q *QRCode) SVG() []byte {
+	bitmap := q.code.Bitmap()
+	size := len(bitmap)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`+"\n",
+		size, size, size*qrPNGModuleSize, size*qrPNGModuleSize)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", size, size)
+	buf.WriteString(`<path fill="#000" d="`)
+	// Each run of dark modules in a row is a rectangle.
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x+1 < len(row) && row[x+1] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start+1, x-start+1)
+		}
+	}
+	buf.WriteString(`"/>` + "\n</svg>\n")
+	return buf.Bytes()
+}
+
+// WriteImages writes the QR code as PNG and SVG images beside the wg-quick config at confPath, either both or neither.
+//
+// The images contain the private key just like the config, they are removed along with it when it is pruned by rendering.
+func (q *QRCode) WriteImages(confPath string) ([]string, error) {
+	png, err := q.PNG()
+	if err != nil {
+		return nil, fmt.Errorf("encoding PNG: %w", err)
+	}
+	contents := map[string][]byte{".png": png, ".svg": q.SVG()}
+	base := strings.TrimSuffix(confPath, ".conf")
+	paths := []string{}
+	pending := []pendingFile{}
+	for _, ext := range qrImageExts {
+		paths = append(paths, base+ext)
+		pending = append(pending, pendingFile{path: base + ext, content: contents[ext], mode: fileModeSensitive})
+	}
+	if err := writeFiles(pending); err != nil {
+		return nil, fmt.Errorf("writing QR code images: %w", err)
+	}
+	return paths, nil
+}
+
+// removeQRImages removes the QR code images written beside the wg-quick config at confPath, if any.
+func removeQRImages(confPath string) error {
+	base := strings.TrimSuffix(confPath, ".conf")
+	for _, ext := range qrImageExts {
+		if err := os.Remove(base + ext); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing QR code image(%s): %w", base+ext, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package rendering
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flexi-cache/pkg/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/example"
+)
+
+func TestQRCode(t *testing.T) {
+	Convey("Encode configs of the example network as QR codes", t, func() {
+		var (
+			conf *config.Config
+			err  error
+		)
+		testutil.WithTempFile(t, example.FileConfExample, func(filename string) {
+			conf, err = config.LoadConfigFromFile(filename)
+		})
+		So(err, ShouldBeNil)
+		now := time.Now()
+
+		content, err := RenderQRContent(conf, "Agu", now)
+		So(err, ShouldBeNil)
+		So(content, ShouldStartWith, "[Interface]\nPrivateKey = private-key-of-agu\nAddress = 192.168.25.15/32\n[Peer]\n")
+		So(content, ShouldNotContainSubstring, "#")
+		So(content, ShouldNotContainSubstring, "\n\n")
+
+		code, err := NewQRCode(content)
+		So(err, ShouldBeNil)
+		So(code.Version(), ShouldBeLessThanOrEqualTo, MaxScannableQRVersion)
+		size := len(code.code.Bitmap())
+
+		Convey("Two rows of modules should be drawn per line in terminals", func() {
+			lines := strings.Split(strings.TrimSuffix(code.Terminal(), "\n"), "\n")
+			So(lines, ShouldHaveLength, (size+1)/2)
+			So(strings.Count(lines[0], "▀"), ShouldEqual, size)
+			So(lines[0], ShouldEndWith, "\x1b[0m")
+		})
+		Convey("Images should be valid", func() {
+			data, err := code.PNG()
+			So(err, ShouldBeNil)
+			img, err := png.Decode(bytes.NewReader(data))
+			So(err, ShouldBeNil)
+			So(img.Bounds().Dx(), ShouldEqual, size*qrPNGModuleSize)
+
+			svg := code.SVG()
+			So(xml.Unmarshal(svg, new(struct{})), ShouldBeNil)
+			So(string(svg), ShouldContainSubstring, "M4 4h7v1h-7z")
+		})
+		Convey("Images should be written beside configs and removed with them", func() {
+			dir, err := ioutil.TempDir("", t.Name())
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+			confPath := path.Join(dir, "Agu", "wg-example.conf")
+
+			paths, err := code.WriteImages(confPath)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{path.Join(dir, "Agu", "wg-example.png"), path.Join(dir, "Agu", "wg-example.svg")})
+			for _, p := range paths {
+				info, err := os.Stat(p)
+				So(err, ShouldBeNil)
+				So(info.Mode().Perm(), ShouldEqual, os.FileMode(fileModeSensitive))
+			}
+
+			So(removeQRImages(confPath), ShouldBeNil)
+			for _, p := range paths {
+				_, err := os.Stat(p)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			}
+			So(removeQRImages(confPath), ShouldBeNil)
+		})
+		Convey("Public-key-only peers should not be encoded", func() {
+			peer, _ := conf.GetPeerByID("Agu")
+			peer.PrivateKey = ""
+			_, err := RenderQRContent(conf, "Agu", now)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Configs too large for any QR code should fail", func() {
+			_, err := NewQRCode(strings.Repeat("AllowedIPs = 192.168.0.0/16\n", 200))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -1,10 +1,17 @@
 package rendering
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/tevino/log"
@@ -16,68 +23,246 @@ const (
 	wgInterfacePrefix = "wg-"
 )
 
-// RenderNetwork render configurations of peers of a network described by networkConfFile into dirPeers.
-func RenderNetwork(conf *config.Config, dirPeers string) error {
+// timeNow returns the current time, it's replaced in tests.
+var timeNow = time.Now
+
+// Options controls how configurations are rendered and written.
+type Options struct {
+	// Force overwrites configs modified manually.
+	Force bool
+	// Timestamp records the time of rendering in configs instead of the hash of the network description,
+	// configs are no longer identical between renderings of the same description if set.
+	Timestamp bool
+}
+
+// RenderedConfig contains the files rendered for a peer within a network.
+type RenderedConfig struct {
+	NetworkID string
+	PeerID    string
+	// PublicKeyOnly is true if the private key in the files is a placeholder, see PrivateKeyPlaceholder.
+	PublicKeyOnly bool
+	Files         []RenderedFile
+}
+
+// RenderedFile is a file rendered for a peer.
+type RenderedFile struct {
+	// Path of the file relative to the peers folder, e.g. Tento/wg-example.conf.
+	Path    string
+	Content []byte
+	// Checksum embedded in Content to detect manual modifications.
+	Checksum string
+	// Mode is the permission of the file, fileModeSensitive if zero.
+	Mode os.FileMode
+}
+
+// FileMode returns the permission the file should be written with.
+func (f *RenderedFile) FileMode() os.FileMode {
+	if f.Mode == 0 {
+		return fileModeSensitive
+	}
+	return f.Mode
+}
+
+// Render renders configurations of peers of a network in memory, disabled and expired peers are skipped.
+//
+// The returned map is keyed by IDs of peers.
+func Render(conf *config.Config, opts Options) (map[string]RenderedConfig, error) {
+	now := timeNow()
+	// The hash is the same for all files of the network.
+	descHash := ""
+	if !opts.Timestamp {
+		var err error
+		if descHash, err = descriptionHash(conf); err != nil {
+			return nil, err
+		}
+	}
+	configs := map[string]RenderedConfig{}
 	for _, p := range conf.Peers {
-		log.Infof("Rendering config for peer: %s\n", p.ID)
-		dirPeer, err := ensurePeersDir(dirPeers, p.ID)
+		active, err := p.IsActiveAt(now)
 		if err != nil {
-			return fmt.Errorf("ensuring folder for peer(%s): %w", p.ID, err)
+			return nil, err
+		}
+		if p.Disabled {
+			log.Debugf("Skipping disabled peer: %s\n", p.ID)
+			continue
 		}
-		confName := wgInterfacePrefix + conf.Network.ID + ".conf"
-		confPath := path.Join(dirPeer, confName)
-		flPeerConf, err := os.OpenFile(confPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fileModeSensitive)
+		if !active {
+			log.Debugf("Skipping expired peer: %s (expired at %s)\n", p.ID, p.ExpiresAt)
+			continue
+		}
+		log.Debugf("Rendering config for peer: %s\n", p.ID)
+		if p.IsPublicKeyOnly() {
+			log.Warnf("Peer %s has no PrivateKey, fill it in on the device in place of %s\n", p.ID, PrivateKeyPlaceholder)
+		}
+		files, err := renderPeerFiles(conf, &p, now, opts, descHash)
 		if err != nil {
-			return fmt.Errorf("opening peer config(%s): %w", confPath, err)
+			return nil, fmt.Errorf("rendering peer config: %w", err)
+		}
+		configs[p.ID] = RenderedConfig{
+			NetworkID:     conf.Network.ID,
+			PeerID:        p.ID,
+			PublicKeyOnly: p.IsPublicKeyOnly(),
+			Files:         files,
 		}
+	}
+	return configs, nil
+}
 
-		err = renderPeerConfig(flPeerConf, conf, p.ID)
-		flPeerConf.Close()
-		if err != nil {
-			return fmt.Errorf("rendering peer config: %w", err)
+// SortedFiles returns all files of configs sorted by their paths.
+func SortedFiles(configs map[string]RenderedConfig) []RenderedFile {
+	files := []RenderedFile{}
+	for _, c := range configs {
+		files = append(files, c.Files...)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// RenderTo renders configurations of peers of a network into sink.
+func RenderTo(conf *config.Config, sink Sink, opts Options) error {
+	configs, err := Render(conf, opts)
+	if err != nil {
+		return err
+	}
+	return sink.WriteNetwork(conf.Network.ID, SortedFiles(configs))
+}
+
+// RenderNetwork render configurations of peers of a network described by networkConfFile into dirPeers.
+//
+// See DirSink for how files are written.
+func RenderNetwork(conf *config.Config, dirPeers string, opts Options) error {
+	return RenderTo(conf, &DirSink{Dir: dirPeers, Force: opts.Force}, opts)
+}
+
+// generatedLine returns the line recording the time of rendering or the hash of the network description in content.
+func generatedLine(content []byte) string {
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if IsGeneratedLine(line) {
+			return line
 		}
 	}
-	return nil
+	return ""
 }
 
-func ensurePeersDir(dirPeers string, peerID string) (string, error) {
-	dirPeer := path.Join(dirPeers, peerID)
-	if _, err := os.Stat(dirPeer); os.IsNotExist(err) {
-		err := os.MkdirAll(dirPeer, fileModeSensitive)
-		if err != nil {
-			return "", fmt.Errorf("creating config folder for peer (%s): %w", peerID, err)
+// recordsTime returns true if the generated line records the time of rendering, which changes on every rendering.
+func recordsTime(line string) bool {
+	return strings.HasPrefix(line, generatedLinePrefix+"at ")
+}
+
+// IsGeneratedLine returns true if line is the one recording the time of rendering or the hash of the network description.
+func IsGeneratedLine(line string) bool {
+	return strings.HasPrefix(line, generatedLinePrefix)
+}
+
+// StripGeneratedLine returns content of a rendered config without the line recording the time of rendering or
+// the hash of the network description, which changes without changing the config.
+func StripGeneratedLine(content []byte) []byte {
+	lines := strings.SplitAfter(string(content), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !IsGeneratedLine(line) {
+			kept = append(kept, line)
 		}
-	} else if err != nil {
-		return "", fmt.Errorf("reading config folder for peer (%s): %w", dirPeer, err)
 	}
-	return dirPeer, nil
+	return []byte(strings.Join(kept, ""))
+}
+
+// PeerConfigPath returns the path of the wg-quick configuration file of a peer within a network.
+func PeerConfigPath(dirPeers string, networkID string, peerID string) string {
+	return peerFilePath(dirPeers, networkID, peerID, ".conf")
+}
+
+func peerFilePath(dirPeers string, networkID string, peerID string, ext string) string {
+	return path.Join(dirPeers, peerID, wgInterfacePrefix+networkID+ext)
+}
+
+// outputFile is a file rendered for a peer in an output format.
+type outputFile struct {
+	// ext is the extension of the file, which is named after the WireGuard interface.
+	ext  string
+	tpl  *template.Template
+	mode os.FileMode
+}
+
+// outputFiles are the files rendered for each output format, see config.Peer.Outputs.
+var outputFiles = map[string][]outputFile{
+	config.OutputWGQuick:  {{ext: ".conf", tpl: tplPeerConfig}},
+	config.OutputNetworkd: {{ext: ".netdev", tpl: tplNetdev}, {ext: ".network", tpl: tplNetwork}},
+	// NetworkManager ignores keyfiles readable by others.
+	config.OutputNetworkManager: {{ext: ".nmconnection", tpl: tplNMConnection, mode: 0600}},
+	config.OutputOpenWrt:        {{ext: ".network.uci", tpl: tplUCINetwork}, {ext: ".firewall.uci", tpl: tplUCIFirewall}},
+	config.OutputRouterOS:       {{ext: ".rsc", tpl: tplRouterOS}},
+	config.OutputVyOS:           {{ext: ".vyos", tpl: tplVyOS}},
+	config.OutputOPNsense:       {{ext: ".opnsense.xml", tpl: tplOPNsense}},
+	config.OutputNixOS:          {{ext: ".nix", tpl: tplNixOS}},
+	config.OutputMobileConfig:   {{ext: ".mobileconfig", tpl: tplMobileConfig}},
 }
 
-func renderPeerConfig(dst io.Writer, conf *config.Config, peerID string) error {
-	peers := []config.Peer{}
-	targetPeer, ok := conf.GetPeerByID(peerID)
-	if !ok {
-		return fmt.Errorf("peer(%s) not found", peerID)
+// renderPeerFiles renders the files of a peer in all of its output formats, descHash is recorded unless opts.Timestamp is set.
+func renderPeerFiles(conf *config.Config, p *config.Peer, now time.Time, opts Options, descHash string) ([]RenderedFile, error) {
+	ctx, err := newPeerConfigTplContext(conf, p.ID, now, opts, descHash)
+	if err != nil {
+		return nil, err
 	}
-	for _, p := range conf.Peers {
-		if p.ID == peerID {
-			continue
+	files := []RenderedFile{}
+	for _, output := range p.Outputs() {
+		outFiles, ok := outputFiles[output]
+		if !ok {
+			return nil, fmt.Errorf("unknown Output(%s) of Peer(%s)", output, p.ID)
 		}
-		// Bounce servers should have all peers in its config.
-		// Client peer only need the bounce server peers.
-		if targetPeer.IsBounceServer() || p.IsBounceServer() {
-			peers = append(peers, p)
+		for _, f := range outFiles {
+			var buf bytes.Buffer
+			if err := executeTemplate(&buf, f.tpl, ctx); err != nil {
+				return nil, err
+			}
+			content := addChecksum(buf.Bytes())
+			files = append(files, RenderedFile{
+				Path:     peerFilePath("", conf.Network.ID, p.ID, f.ext),
+				Content:  content,
+				Checksum: checksum(content),
+				Mode:     f.mode,
+			})
 		}
 	}
+	return files, nil
+}
+
+func newPeerConfigTplContext(conf *config.Config, peerID string, now time.Time, opts Options, descHash string) (*PeerConfigTplContext, error) {
+	wgConf, err := BuildPeerConfig(conf, peerID, now)
+	if err != nil {
+		return nil, err
+	}
+	peer, _ := conf.GetPeerByID(peerID)
 	ctx := &PeerConfigTplContext{
-		Network:     &conf.Network,
-		Interface:   targetPeer,
-		Peers:       peers,
-		GeneratedAt: time.Now().Local(),
+		Network: &conf.Network,
+		Config:  wgConf,
+		Peer:    peer,
 	}
-	err := tplPeerConfig.Execute(dst, ctx)
+	if opts.Timestamp {
+		ctx.GeneratedAt = now.Local()
+	} else {
+		ctx.DescriptionHash = descHash
+	}
+	return ctx, nil
+}
+
+func executeTemplate(dst io.Writer, tpl *template.Template, ctx *PeerConfigTplContext) error {
+	if err := tpl.Execute(dst, ctx); err != nil {
+		return fmt.Errorf("rendering %s for Peer(%s): %w", tpl.Name(), ctx.Config.Interface.ID, err)
+	}
+	return nil
+}
+
+// descriptionHash returns the hash of the content of a network description,
+// comments, formatting and the order of peers are ignored.
+func descriptionHash(conf *config.Config) (string, error) {
+	sorted := *conf
+	sorted.Peers = append([]config.Peer{}, conf.Peers...)
+	sort.SliceStable(sorted.Peers, func(i, j int) bool { return sorted.Peers[i].ID < sorted.Peers[j].ID })
+	data, err := json.Marshal(&sorted)
 	if err != nil {
-		err = fmt.Errorf("rendering config for Peer(%s): %w", ctx.Interface.ID, err)
+		return "", fmt.Errorf("encoding network description: %w", err)
 	}
-	return err
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
 }
@@ -1,10 +1,17 @@
 package rendering
 
 import (
+	"archive/tar"
 	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/flexi-cache/pkg/testutil"
 	. "github.com/smartystreets/goconvey/convey"
@@ -24,11 +31,7 @@ func TestRenderPeerConfig(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(conf, ShouldNotBeNil)
 
-		var buf bytes.Buffer
-
-		err = renderPeerConfig(&buf, conf, "Tento")
-		So(err, ShouldBeNil)
-		confTento := buf.String()
+		confTento := renderWgQuick(conf, "Tento", time.Now(), Options{})
 		Convey("Config of Tento should contain expected contents", func() {
 			So(confTento, ShouldContainSubstring, "[Interface]")
 			So(confTento, ShouldContainSubstring, "# ID = Tento")
@@ -47,10 +50,7 @@ func TestRenderPeerConfig(t *testing.T) {
 			So(regexp.MustCompile("(?m)= ?$").MatchString(confTento), ShouldBeFalse)
 		})
 
-		buf.Reset()
-		err = renderPeerConfig(&buf, conf, "Pata")
-		So(err, ShouldBeNil)
-		confPata := buf.String()
+		confPata := renderWgQuick(conf, "Pata", time.Now(), Options{})
 		Convey("Config of Pata should contain expected contents", func() {
 			So(confPata, ShouldContainSubstring, "[Interface]")
 			So(confPata, ShouldContainSubstring, "# ID = Pata")
@@ -86,6 +86,53 @@ func TestRenderPeerConfig(t *testing.T) {
 			So(confPata, ShouldNotContainSubstring, "PersistentKeepalive")
 		})
 
+		Convey("Disabled peers should be left out", func() {
+			agu, _ := conf.GetPeerByID("Agu")
+			agu.Disabled = true
+
+			rendered := renderWgQuick(conf, "Pata", time.Now(), Options{})
+			So(rendered, ShouldNotContainSubstring, "# ID = Agu")
+		})
+		Convey("PresharedKeys of clients should be used on both sides", func() {
+			tento, _ := conf.GetPeerByID("Tento")
+			tento.PresharedKey = "psk-of-tento"
+
+			rendered := renderWgQuick(conf, "Pata", time.Now(), Options{})
+			So(rendered, ShouldContainSubstring, "PublicKey = public-key-of-tento\nPresharedKey = psk-of-tento\n")
+			So(strings.Count(rendered, "PresharedKey"), ShouldEqual, 1)
+
+			rendered = renderWgQuick(conf, "Tento", time.Now(), Options{})
+			So(rendered, ShouldContainSubstring, "PresharedKey = psk-of-tento")
+		})
+		Convey("Expired peers should be left out", func() {
+			now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.Local)
+			agu, _ := conf.GetPeerByID("Agu")
+			agu.ExpiresAt = "2020-05-31"
+
+			rendered := renderWgQuick(conf, "Pata", now, Options{})
+			So(rendered, ShouldNotContainSubstring, "# ID = Agu")
+			So(rendered, ShouldContainSubstring, "# ID = Tento")
+
+			rendered = renderWgQuick(conf, "Pata", now.AddDate(0, 0, -2), Options{})
+			So(rendered, ShouldContainSubstring, "# ID = Agu")
+		})
+		Convey("GeneratedAt should be the given time", func() {
+			now := time.Date(2020, 6, 1, 12, 30, 0, 0, time.Local)
+			rendered := renderWgQuick(conf, "Pata", now, Options{Timestamp: true})
+			So(rendered, ShouldContainSubstring, "Generated by wg-make at 2020-06-01 12:30:00")
+		})
+
+		Convey("Public-key-only peers should be rendered with a placeholder", func() {
+			agu, _ := conf.GetPeerByID("Agu")
+			agu.PrivateKey = ""
+
+			rendered := renderWgQuick(conf, "Agu", time.Now(), Options{})
+			So(rendered, ShouldContainSubstring, "PrivateKey = "+PrivateKeyPlaceholder)
+
+			rendered = renderWgQuick(conf, "Pata", time.Now(), Options{})
+			So(rendered, ShouldContainSubstring, "PublicKey = public-key-of-agu")
+		})
+
 		// general validations
 		Convey("Sections are at the begin of lines", func() {
 			rePeer := regexp.MustCompile(`(?m)^\[Peer\]$`)
@@ -108,3 +155,587 @@ func TestRenderPeerConfig(t *testing.T) {
 		})
 	})
 }
+
+func TestRender(t *testing.T) {
+	Convey("Render example config in memory", t, func() {
+		var (
+			conf *config.Config
+			err  error
+		)
+		testutil.WithTempFile(t, example.FileConfExample, func(filename string) {
+			conf, err = config.LoadConfigFromFile(filename)
+		})
+		So(err, ShouldBeNil)
+
+		configs, err := Render(conf, Options{})
+		So(err, ShouldBeNil)
+		So(configs, ShouldHaveLength, 3)
+		tento := configs["Tento"]
+		So(tento.PeerID, ShouldEqual, "Tento")
+		So(tento.NetworkID, ShouldEqual, "example")
+		So(tento.PublicKeyOnly, ShouldBeFalse)
+		So(tento.Files, ShouldHaveLength, 1)
+		So(tento.Files[0].Path, ShouldEqual, "Tento/wg-example.conf")
+		So(string(tento.Files[0].Content), ShouldContainSubstring, "# ID = Tento")
+		So(string(tento.Files[0].Content), ShouldContainSubstring, "# Checksum: sha256:"+tento.Files[0].Checksum)
+
+		files := SortedFiles(configs)
+		So(files, ShouldHaveLength, 3)
+		So(files[0].Path, ShouldEqual, "Agu/wg-example.conf")
+		So(files[2].Path, ShouldEqual, "Tento/wg-example.conf")
+
+		Convey("Configs should be identical between renderings", func() {
+			conf.Peers[0], conf.Peers[1], conf.Peers[2] = conf.Peers[2], conf.Peers[0], conf.Peers[1]
+			timeNow = func() time.Time { return time.Now().Add(time.Hour) }
+			defer func() { timeNow = time.Now }()
+			again, err := Render(conf, Options{})
+			So(err, ShouldBeNil)
+			So(again, ShouldResemble, configs)
+			So(string(tento.Files[0].Content), ShouldContainSubstring, "Generated by wg-make from network description sha256:")
+		})
+		Convey("The generated line should be stripped", func() {
+			stripped := string(StripGeneratedLine(tento.Files[0].Content))
+			So(stripped, ShouldNotContainSubstring, "Generated by wg-make")
+			So(stripped, ShouldContainSubstring, "# CAUTION: DO NOT modify this file manually.\n")
+			So(len(stripped), ShouldBeLessThan, len(tento.Files[0].Content))
+		})
+		Convey("Configs for systemd-networkd should be rendered if selected", func() {
+			tentoPeer, _ := conf.GetPeerByID("Tento")
+			tentoPeer.Output = "wg-quick,networkd"
+			pataPeer, _ := conf.GetPeerByID("Pata")
+			pataPeer.Output = config.OutputNetworkd
+			files := renderPaths(conf, map[string][]string{
+				"Tento": {"Tento/wg-example.conf", "Tento/wg-example.netdev", "Tento/wg-example.network"},
+				"Pata":  {"Pata/wg-example.netdev", "Pata/wg-example.network"},
+			})
+			netdev, network := string(files["Tento"][1].Content), string(files["Tento"][2].Content)
+			So(netdev, ShouldContainSubstring, "# Checksum: sha256:"+files["Tento"][1].Checksum)
+			So(netdev, ShouldContainSubstring, "[NetDev]\nName = wg-example\nKind = wireguard\n")
+			So(netdev, ShouldContainSubstring, "[WireGuard]\n# ID = Tento\nPrivateKey = private-key-of-tento\n")
+			So(netdev, ShouldContainSubstring, "RouteTable = main\n")
+			So(netdev, ShouldContainSubstring, "[WireGuardPeer]\n# ID = Pata\nEndpoint = pata.example.com:49736\n"+
+				"PublicKey = public-key-of-pata\nAllowedIPs = 192.168.25.1/32,192.168.25.0/24\nPersistentKeepalive = 25\n")
+			So(netdev, ShouldNotContainSubstring, "# ID = Agu")
+			So(network, ShouldContainSubstring, "[Match]\nName = wg-example\n")
+			So(network, ShouldContainSubstring, "[Network]\nAddress = 192.168.25.55/32\n")
+			So(network, ShouldNotContainSubstring, "IPForward")
+
+			So(string(files["Pata"][0].Content), ShouldContainSubstring, "ListenPort = 49736\n")
+			So(string(files["Pata"][0].Content), ShouldContainSubstring, "# ID = Agu")
+			So(string(files["Pata"][1].Content), ShouldContainSubstring, "IPForward = yes\nIPMasquerade = both\n")
+		})
+		Convey("Keyfiles for NetworkManager should be rendered if selected", func() {
+			tentoPeer, _ := conf.GetPeerByID("Tento")
+			tentoPeer.Output = config.OutputNetworkManager
+			tentoPeer.DNS = "192.168.25.1,fd00::1,corp.example.com"
+			files := renderPaths(conf, map[string][]string{
+				"Tento": {"Tento/wg-example.nmconnection"},
+				"Pata":  {"Pata/wg-example.conf"},
+			})
+			So(files["Tento"][0].FileMode(), ShouldEqual, os.FileMode(0600))
+			So(files["Pata"][0].FileMode(), ShouldEqual, os.FileMode(fileModeSensitive))
+			keyfile := string(files["Tento"][0].Content)
+			So(keyfile, ShouldContainSubstring, "[connection]\nid=wg-example\nuuid=")
+			So(keyfile, ShouldContainSubstring, "type=wireguard\ninterface-name=wg-example\n")
+			So(keyfile, ShouldContainSubstring, "[wireguard]\n# ID = Tento\nprivate-key=private-key-of-tento\n")
+			So(keyfile, ShouldContainSubstring, "[wireguard-peer.public-key-of-pata]\n# ID = Pata\nendpoint=pata.example.com:49736\n"+
+				"allowed-ips=192.168.25.1/32;192.168.25.0/24;\npersistent-keepalive=25\n")
+			So(keyfile, ShouldContainSubstring, "[ipv4]\nmethod=manual\naddress1=192.168.25.55/32\ndns=192.168.25.1;\n")
+			So(keyfile, ShouldContainSubstring, "dns-search=corp.example.com;\n")
+			So(keyfile, ShouldEndWith, "[ipv6]\nmethod=disabled\n")
+
+			again, err := Render(conf, Options{})
+			So(err, ShouldBeNil)
+			So(again["Tento"].Files[0].Content, ShouldResemble, files["Tento"][0].Content)
+		})
+		Convey("UCI configs should be rendered for OpenWrt", func() {
+			pataPeer, _ := conf.GetPeerByID("Pata")
+			pataPeer.OS = config.OSOpenWrt
+			files := renderPaths(conf, map[string][]string{
+				"Pata":  {"Pata/wg-example.network.uci", "Pata/wg-example.firewall.uci"},
+				"Tento": {"Tento/wg-example.conf"},
+			})
+			network, firewall := string(files["Pata"][0].Content), string(files["Pata"][1].Content)
+			So(network, ShouldContainSubstring, "config interface 'wg_example'\n\t# ID = Pata\n\toption proto 'wireguard'\n"+
+				"\toption private_key 'private-key-of-pata'\n\toption listen_port '49736'\n\tlist addresses '192.168.25.1/32'\n")
+			So(network, ShouldContainSubstring, "config wireguard_wg_example\n\toption description 'Tento'\n\toption public_key 'public-key-of-tento'\n")
+			So(network, ShouldContainSubstring, "\tlist allowed_ips '192.168.25.55/32'\n")
+			So(network, ShouldNotContainSubstring, "PostUp")
+			So(firewall, ShouldContainSubstring, "config zone\n\toption name 'wg_example'\n\tlist network 'wg_example'\n")
+			So(firewall, ShouldContainSubstring, "\toption forward 'ACCEPT'\n")
+			So(firewall, ShouldContainSubstring, "\toption dest_port '49736'\n")
+			So(firewall, ShouldContainSubstring, "config forwarding\n\toption src 'lan'\n\toption dest 'wg_example'\n")
+			So(firewall, ShouldContainSubstring, "config forwarding\n\toption src 'wg_example'\n\toption dest 'wan'\n")
+
+			Convey("Endpoints should be split into hosts and ports", func() {
+				tentoPeer, _ := conf.GetPeerByID("Tento")
+				tentoPeer.OS = config.OSOpenWrt
+				files := renderPaths(conf, map[string][]string{
+					"Tento": {"Tento/wg-example.network.uci", "Tento/wg-example.firewall.uci"},
+				})
+				network := string(files["Tento"][0].Content)
+				So(network, ShouldContainSubstring, "\toption endpoint_host 'pata.example.com'\n\toption endpoint_port '49736'\n")
+				So(string(files["Tento"][1].Content), ShouldNotContainSubstring, "config forwarding")
+			})
+			Convey("Values should be quoted for UCI", func() {
+				ctx := &PeerConfigTplContext{Network: &config.Network{ID: "home-1"}}
+				So(ctx.UCIInterface(), ShouldEqual, "wg_home_1")
+				So(ctx.ShellQuote("it's"), ShouldEqual, `'it'\''s'`)
+			})
+		})
+		Convey("RouterOS scripts should be rendered", func() {
+			pataPeer, _ := conf.GetPeerByID("Pata")
+			pataPeer.OS = config.OSRouterOS
+			tentoPeer, _ := conf.GetPeerByID("Tento")
+			tentoPeer.Output = config.OutputRouterOS
+			files := renderPaths(conf, map[string][]string{
+				"Pata":  {"Pata/wg-example.rsc"},
+				"Tento": {"Tento/wg-example.rsc"},
+			})
+			script := string(files["Pata"][0].Content)
+			So(script, ShouldContainSubstring, "\n/interface wireguard peers remove [find comment=\"wg-make:example\"]\n"+
+				"/interface wireguard remove [find comment=\"wg-make:example\"]\n")
+			So(strings.Index(script, " remove "), ShouldBeLessThan, strings.Index(script, " add "))
+			So(script, ShouldContainSubstring, "/interface wireguard add name=wg-example comment=\"wg-make:example\" "+
+				"private-key=\"private-key-of-pata\" listen-port=49736\n")
+			So(script, ShouldContainSubstring, "/interface wireguard peers add interface=wg-example comment=\"wg-make:example\" "+
+				"public-key=\"public-key-of-tento\" allowed-address=192.168.25.55/32\n")
+			So(script, ShouldContainSubstring, "/ip address add interface=wg-example comment=\"wg-make:example\" address=192.168.25.1/32\n")
+			So(script, ShouldContainSubstring, "/ip route add gateway=wg-example comment=\"wg-make:example\" dst-address=192.168.25.55/32\n")
+			So(script, ShouldContainSubstring, "/ip firewall nat add chain=srcnat action=masquerade comment=\"wg-make:example\" "+
+				"src-address=192.168.25.0/24 out-interface=eth0\n")
+
+			script = string(files["Tento"][0].Content)
+			So(script, ShouldContainSubstring, " endpoint-address=pata.example.com endpoint-port=49736 persistent-keepalive=25s "+
+				"allowed-address=192.168.25.1/32,192.168.25.0/24\n")
+			So(strings.Count(script, "/ip route add "), ShouldEqual, 2)
+			So(script, ShouldNotContainSubstring, "firewall nat add")
+
+			Convey("Values should be quoted for RouterOS", func() {
+				ctx := &PeerConfigTplContext{}
+				So(ctx.RouterOSQuote(`a"b$c\d`), ShouldEqual, `"a\"b\$c\\d"`)
+				So(ctx.RouterOSMenu("fd00::1/128"), ShouldEqual, "/ipv6")
+			})
+		})
+		Convey("VyOS commands should be rendered", func() {
+			pataPeer, _ := conf.GetPeerByID("Pata")
+			pataPeer.OS = config.OSVyOS
+			pataPeer.InterfaceName = "wg0"
+			tentoPeer, _ := conf.GetPeerByID("Tento")
+			tentoPeer.Output = config.OutputVyOS
+			tentoPeer.InterfaceName = "wg3"
+			files := renderPaths(conf, map[string][]string{
+				"Pata":  {"Pata/wg-example.vyos"},
+				"Tento": {"Tento/wg-example.vyos"},
+			})
+			commands := string(files["Pata"][0].Content)
+			So(commands, ShouldContainSubstring, "\ndelete interfaces wireguard wg0\n")
+			So(commands, ShouldContainSubstring, "set interfaces wireguard wg0 private-key 'private-key-of-pata'\n"+
+				"set interfaces wireguard wg0 address '192.168.25.1/32'\nset interfaces wireguard wg0 port '49736'\n")
+			So(commands, ShouldContainSubstring, "set interfaces wireguard wg0 peer Tento public-key 'public-key-of-tento'\n"+
+				"set interfaces wireguard wg0 peer Tento allowed-ips '192.168.25.55/32'\n")
+			So(commands, ShouldContainSubstring, "set protocols static route 192.168.25.55/32 interface wg0\n")
+			cleanup := "for prefix in $(cli-shell-api listNodes protocols static route | tr -d \"'\"); do " +
+				"cli-shell-api exists protocols static route \"$prefix\" interface wg0 && delete protocols static route \"$prefix\"; done\n"
+			So(commands, ShouldContainSubstring, cleanup)
+			So(commands, ShouldContainSubstring, "listNodes protocols static route6 ")
+			So(strings.Index(commands, cleanup), ShouldBeLessThan, strings.Index(commands, "set protocols static route "))
+			So(commands, ShouldContainSubstring, "set nat source rule 5095 outbound-interface name 'eth0'\n"+
+				"set nat source rule 5095 source address '192.168.25.0/24'\n"+
+				"set nat source rule 5095 translation address 'masquerade'\n")
+
+			commands = string(files["Tento"][0].Content)
+			So(commands, ShouldContainSubstring, "set interfaces wireguard wg3 peer Pata allowed-ips '192.168.25.1/32'\n"+
+				"set interfaces wireguard wg3 peer Pata allowed-ips '192.168.25.0/24'\n"+
+				"set interfaces wireguard wg3 peer Pata host-name 'pata.example.com'\n"+
+				"set interfaces wireguard wg3 peer Pata port '49736'\n"+
+				"set interfaces wireguard wg3 peer Pata persistent-keepalive '25'\n")
+			So(commands, ShouldContainSubstring, "set protocols static route 192.168.25.0/24 interface wg3\n")
+			So(commands, ShouldNotContainSubstring, " nat ")
+
+			Convey("Endpoints of IP addresses should be set as addresses", func() {
+				pataPeer.Endpoint = "[2001:db8::1]:49736"
+				files := renderPaths(conf, map[string][]string{"Tento": {"Tento/wg-example.vyos"}})
+				So(string(files["Tento"][0].Content), ShouldContainSubstring, "peer Pata address '2001:db8::1'\n")
+			})
+		})
+		Convey("XML fragments should be rendered for OPNsense", func() {
+			pataPeer, _ := conf.GetPeerByID("Pata")
+			pataPeer.OS = config.OSOPNsense
+			pataPeer.PublicInterface = "wan"
+			tentoPeer, _ := conf.GetPeerByID("Tento")
+			tentoPeer.OS = config.OSOPNsense
+			tentoPeer.InterfaceName = "wg2"
+			files := renderPaths(conf, map[string][]string{
+				"Pata":  {"Pata/wg-example.opnsense.xml"},
+				"Tento": {"Tento/wg-example.opnsense.xml"},
+			})
+			fragment := files["Pata"][0].Content
+			So(xml.Unmarshal(fragment, new(struct{})), ShouldBeNil)
+			So(IsModifiedManually(fragment), ShouldBeFalse)
+			So(string(StripGeneratedLine(fragment)), ShouldNotContainSubstring, "Generated by wg-make")
+			So(string(fragment), ShouldContainSubstring, "<name>wg-example</name>\n            <instance>0</instance>\n"+
+				"            <pubkey>public-key-of-pata</pubkey>\n            <privkey>private-key-of-pata</privkey>\n"+
+				"            <port>49736</port>\n")
+			So(string(fragment), ShouldContainSubstring, "<name>Tento</name>\n            <pubkey>public-key-of-tento</pubkey>\n"+
+				"            <psk></psk>\n            <tunneladdress>192.168.25.55/32</tunneladdress>\n")
+			So(string(fragment), ShouldContainSubstring, "<interface>wireguard</interface>")
+			So(string(fragment), ShouldContainSubstring, "<interface>wan</interface>\n        <ipprotocol>inet</ipprotocol>\n"+
+				"        <source>\n          <network>192.168.25.0/24</network>\n")
+
+			fragment = files["Tento"][0].Content
+			So(xml.Unmarshal(fragment, new(struct{})), ShouldBeNil)
+			So(string(fragment), ShouldContainSubstring, "<instance>2</instance>")
+			So(string(fragment), ShouldContainSubstring, "<serveraddress>pata.example.com</serveraddress>\n"+
+				"            <serverport>49736</serverport>\n            <keepalive>25</keepalive>\n")
+			So(string(fragment), ShouldNotContainSubstring, "<filter>")
+			So(string(fragment), ShouldNotContainSubstring, "<nat>")
+		})
+		Convey("NixOS modules should be rendered", func() {
+			pataPeer, _ := conf.GetPeerByID("Pata")
+			pataPeer.OS = config.OSNixOS
+			tentoPeer, _ := conf.GetPeerByID("Tento")
+			tentoPeer.Output = config.OutputNixOS
+			tentoPeer.PresharedKey = "psk-of-tento"
+			files := renderPaths(conf, map[string][]string{
+				"Pata":  {"Pata/wg-example.nix"},
+				"Tento": {"Tento/wg-example.nix"},
+			})
+			module := string(files["Pata"][0].Content)
+			So(module, ShouldContainSubstring, "#   /etc/wireguard/keys/wg-example.key: PrivateKey of Pata\n")
+			So(module, ShouldContainSubstring, "#   /etc/wireguard/keys/wg-example-Tento.psk: PresharedKey with Tento\n")
+			So(module, ShouldNotContainSubstring, "private-key-of-pata")
+			So(module, ShouldNotContainSubstring, "psk-of-tento")
+			So(module, ShouldContainSubstring, `networking.wireguard.interfaces."wg-example" = {`)
+			So(module, ShouldContainSubstring, `    ips = [ "192.168.25.1/32" ];
+    listenPort = 49736;
+    privateKeyFile = "/etc/wireguard/keys/wg-example.key";
+`)
+			So(module, ShouldContainSubstring, `        publicKey = "public-key-of-tento";
+        presharedKeyFile = "/etc/wireguard/keys/wg-example-Tento.psk";
+        allowedIPs = [ "192.168.25.55/32" ];
+`)
+			So(module, ShouldContainSubstring, "networking.firewall.allowedUDPPorts = [ 49736 ];\n")
+			So(module, ShouldContainSubstring, `"net.ipv4.ip_forward" = 1;`)
+			So(module, ShouldContainSubstring, `    externalInterface = "eth0";
+    internalInterfaces = [ "wg-example" ];
+`)
+			So(module, ShouldNotContainSubstring, "PostUp")
+
+			module = string(files["Tento"][0].Content)
+			So(module, ShouldContainSubstring, `        allowedIPs = [ "192.168.25.1/32" "192.168.25.0/24" ];
+        endpoint = "pata.example.com:49736";
+        persistentKeepalive = 25;
+`)
+			So(module, ShouldNotContainSubstring, "networking.nat")
+			So(module, ShouldNotContainSubstring, "allowedUDPPorts")
+
+			Convey("Private keys kept on devices should be pointed out without placeholders", func() {
+				pataPeer.PrivateKey = ""
+				files := renderPaths(conf, map[string][]string{"Pata": {"Pata/wg-example.nix"}})
+				module := string(files["Pata"][0].Content)
+				So(module, ShouldContainSubstring, "#   /etc/wireguard/keys/wg-example.key: PrivateKey of Pata, which never leaves the device\n")
+				So(module, ShouldNotContainSubstring, PrivateKeyPlaceholder)
+			})
+			Convey("Values should be quoted for Nix", func() {
+				ctx := &PeerConfigTplContext{}
+				So(ctx.NixString(`a"b\\c${d}`), ShouldEqual, `"a\"b\\\\c\${d}"`)
+			})
+		})
+		Convey("Apple profiles should be rendered with on-demand rules", func() {
+			tentoPeer, _ := conf.GetPeerByID("Tento")
+			tentoPeer.OS = config.OSiOS
+			tentoPeer.TrustedSSIDs = "Office, Home & Garden"
+			aguPeer, _ := conf.GetPeerByID("Agu")
+			aguPeer.OS = config.OSMacOS
+			aguPeer.LocalSubnets = "fd00::/64"
+			files := renderPaths(conf, map[string][]string{
+				"Tento": {"Tento/wg-example.mobileconfig"},
+				"Agu":   {"Agu/wg-example.mobileconfig"},
+			})
+			profile := files["Tento"][0].Content
+			So(string(profile), ShouldStartWith, xml.Header)
+			So(xml.Unmarshal(profile, new(struct{})), ShouldBeNil)
+			So(IsModifiedManually(profile), ShouldBeFalse)
+			So(string(profile), ShouldContainSubstring, "<string>com.wireguard.ios</string>")
+			So(string(profile), ShouldContainSubstring, "<key>WgQuickConfig</key>\n        <string>[Interface]\n# ID = Tento\n"+
+				"PrivateKey = private-key-of-tento\n")
+			So(string(profile), ShouldContainSubstring, "Endpoint = pata.example.com:49736\n")
+			So(string(profile), ShouldContainSubstring, "<key>RemoteAddress</key>\n        <string>pata.example.com</string>\n")
+			So(string(profile), ShouldContainSubstring, "<key>SSIDMatch</key>\n            <array>\n"+
+				"              <string>Office</string>\n              <string>Home &amp; Garden</string>\n")
+			So(string(profile), ShouldContainSubstring, "<key>DNSServerAddressMatch</key>\n            <array>\n"+
+				"              <string>10.1.1.*</string>\n")
+
+			profile = files["Agu"][0].Content
+			So(string(profile), ShouldContainSubstring, "<string>com.wireguard.macos</string>")
+			So(string(profile), ShouldNotContainSubstring, "OnDemandEnabled")
+
+			Convey("LocalSubnets should be expanded to patterns of whole octets", func() {
+				ctx := &PeerConfigTplContext{Peer: &config.Peer{LocalSubnets: "10.0.0.0/8,172.16.0.0/15,192.168.1.0/28,fd00::/64"}}
+				So(ctx.AppleLocalDNSMatches(), ShouldResemble, []string{"10.*", "172.16.*", "172.17.*"})
+			})
+		})
+		Convey("Placeholders of private keys should be explained in every format", func() {
+			aguPeer, _ := conf.GetPeerByID("Agu")
+			aguPeer.PrivateKey = ""
+			aguPeer.InterfaceName = "wg1"
+			note := "The private key of this peer never leaves the device, replace the placeholder below with it."
+			for _, c := range []struct {
+				output string
+				prefix string
+			}{
+				{config.OutputWGQuick, "# "},
+				{config.OutputNetworkd, "# "},
+				{config.OutputNetworkManager, "# "},
+				{config.OutputOpenWrt, "\t# "},
+				{config.OutputRouterOS, "# "},
+				{config.OutputVyOS, "# "},
+				{config.OutputOPNsense, "<!-- "},
+				{config.OutputMobileConfig, "# "},
+			} {
+				aguPeer.Output = c.output
+				configs, err := Render(conf, Options{})
+				So(err, ShouldBeNil)
+				content := string(configs["Agu"].Files[0].Content)
+				So(content, ShouldContainSubstring, c.prefix+note)
+				So(strings.Count(content, note), ShouldEqual, 1)
+			}
+		})
+		Convey("Files should be written to a tar archive", func() {
+			var buf bytes.Buffer
+			sink := NewTarSink(&buf)
+			So(RenderTo(conf, sink, Options{}), ShouldBeNil)
+			So(sink.Close(), ShouldBeNil)
+
+			r := tar.NewReader(&buf)
+			for _, f := range files {
+				hdr, err := r.Next()
+				So(err, ShouldBeNil)
+				So(hdr.Name, ShouldEqual, f.Path)
+				content, err := ioutil.ReadAll(r)
+				So(err, ShouldBeNil)
+				So(string(content), ShouldEqual, string(f.Content))
+			}
+			_, err = r.Next()
+			So(err, ShouldEqual, io.EOF)
+		})
+	})
+}
+
+func TestRenderNetwork(t *testing.T) {
+	Convey("Render example config into a folder", t, func() {
+		var (
+			conf *config.Config
+			err  error
+		)
+		testutil.WithTempFile(t, example.FileConfExample, func(filename string) {
+			conf, err = config.LoadConfigFromFile(filename)
+		})
+		So(err, ShouldBeNil)
+		dirPeers, err := ioutil.TempDir("", t.Name())
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dirPeers)
+
+		So(RenderNetwork(conf, dirPeers, Options{}), ShouldBeNil)
+		m, err := LoadManifest(dirPeers)
+		So(err, ShouldBeNil)
+		So(m.Networks["example"], ShouldHaveLength, 3)
+		So(m.Networks["example"], ShouldContainKey, "Agu/wg-example.conf")
+
+		Convey("Files should be written with their modes", func() {
+			tento, _ := conf.GetPeerByID("Tento")
+			tento.Output = "wg-quick,networkmanager"
+			So(RenderNetwork(conf, dirPeers, Options{}), ShouldBeNil)
+			info, err := os.Stat(path.Join(dirPeers, "Tento", "wg-example.nmconnection"))
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+			info, err = os.Stat(path.Join(dirPeers, "Tento", "wg-example.conf"))
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(fileModeSensitive))
+		})
+		Convey("Unchanged files should be left untouched", func() {
+			pathTento := path.Join(dirPeers, "Tento", "wg-example.conf")
+			before, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+
+			timeNow = func() time.Time { return time.Now().Add(time.Hour) }
+			defer func() { timeNow = time.Now }()
+			So(RenderNetwork(conf, dirPeers, Options{}), ShouldBeNil)
+			after, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+			So(string(after), ShouldEqual, string(before))
+		})
+		Convey("The hash of the network description should be updated in unchanged files", func() {
+			pathTento := path.Join(dirPeers, "Tento", "wg-example.conf")
+			before, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+
+			// Configs of Tento are not affected by Agu.
+			conf.Peers[2].PersistentKeepalive = 15
+			So(RenderNetwork(conf, dirPeers, Options{}), ShouldBeNil)
+			after, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+			So(string(after), ShouldNotEqual, string(before))
+			So(string(StripGeneratedLine(after)), ShouldEqual, string(StripGeneratedLine(before)))
+		})
+		Convey("The time of rendering should be kept in unchanged files", func() {
+			pathTento := path.Join(dirPeers, "Tento", "wg-example.conf")
+			So(RenderNetwork(conf, dirPeers, Options{Timestamp: true}), ShouldBeNil)
+			before, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+
+			timeNow = func() time.Time { return time.Now().Add(time.Hour) }
+			defer func() { timeNow = time.Now }()
+			So(RenderNetwork(conf, dirPeers, Options{Timestamp: true}), ShouldBeNil)
+			after, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+			So(string(after), ShouldEqual, string(before))
+		})
+		Convey("Files of removed peers should be pruned along with their QR codes", func() {
+			code, err := NewQRCode("[Interface]\n")
+			So(err, ShouldBeNil)
+			_, err = code.WriteImages(path.Join(dirPeers, "Agu", "wg-example.conf"))
+			So(err, ShouldBeNil)
+
+			conf.Peers = conf.Peers[:2]
+			So(RenderNetwork(conf, dirPeers, Options{}), ShouldBeNil)
+			_, err = os.Stat(path.Join(dirPeers, "Agu"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+
+			m, err := LoadManifest(dirPeers)
+			So(err, ShouldBeNil)
+			So(m.Networks["example"], ShouldHaveLength, 2)
+		})
+		Convey("Previous output should be kept intact on failure", func() {
+			pathTento := path.Join(dirPeers, "Tento", "wg-example.conf")
+			before, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+			conf.Peers[1].PersistentKeepalive = 10
+			conf.Peers[0].PersistentKeepalive = 10
+
+			Convey("Failing to render", func() {
+				conf.Peers[2].AllowedIPs = "invalid"
+				So(RenderNetwork(conf, dirPeers, Options{}), ShouldNotBeNil)
+			})
+			Convey("Failing to write", func() {
+				dirAgu := path.Join(dirPeers, "Agu")
+				So(os.RemoveAll(dirAgu), ShouldBeNil)
+				So(ioutil.WriteFile(dirAgu, nil, 0600), ShouldBeNil)
+				So(RenderNetwork(conf, dirPeers, Options{}), ShouldNotBeNil)
+			})
+
+			after, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+			So(string(after), ShouldEqual, string(before))
+			files, err := ioutil.ReadDir(path.Dir(pathTento))
+			So(err, ShouldBeNil)
+			So(files, ShouldHaveLength, 1)
+		})
+		Convey("Folders created should be removed on failure", func() {
+			blocker := path.Join(dirPeers, "blocker")
+			So(ioutil.WriteFile(blocker, nil, 0600), ShouldBeNil)
+			err := writeFiles([]pendingFile{
+				{path: path.Join(dirPeers, "a", "b", "c", "wg-example.conf"), mode: fileModeSensitive},
+				{path: path.Join(blocker, "wg-example.conf"), mode: fileModeSensitive},
+			})
+			So(err, ShouldNotBeNil)
+			_, err = os.Stat(path.Join(dirPeers, "a"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+		Convey("Configs modified manually should not be overwritten", func() {
+			pathTento := path.Join(dirPeers, "Tento", "wg-example.conf")
+			rendered, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+			So(IsModifiedManually(rendered), ShouldBeFalse)
+
+			modified := strings.Replace(string(rendered), "PersistentKeepalive = 25", "PersistentKeepalive = 15", 1)
+			So(IsModifiedManually([]byte(modified)), ShouldBeTrue)
+			So(ioutil.WriteFile(pathTento, []byte(modified), 0600), ShouldBeNil)
+
+			conf.Peers[0].PersistentKeepalive = 20
+			err = RenderNetwork(conf, dirPeers, Options{})
+			So(err, ShouldHaveSameTypeAs, &ManualEditError{})
+			So(err.(*ManualEditError).Edits, ShouldHaveLength, 1)
+			So(err.(*ManualEditError).Edits[0].Path, ShouldEqual, "Tento/wg-example.conf")
+			current, err := ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+			So(string(current), ShouldEqual, modified)
+
+			So(RenderNetwork(conf, dirPeers, Options{Force: true}), ShouldBeNil)
+			current, err = ioutil.ReadFile(pathTento)
+			So(err, ShouldBeNil)
+			So(string(current), ShouldContainSubstring, "PersistentKeepalive = 20")
+			So(IsModifiedManually(current), ShouldBeFalse)
+		})
+		Convey("Stale configs modified manually should not be removed", func() {
+			pathAgu := path.Join(dirPeers, "Agu", "wg-example.conf")
+			rendered, err := ioutil.ReadFile(pathAgu)
+			So(err, ShouldBeNil)
+			modified := strings.Replace(string(rendered), "PersistentKeepalive = 5", "PersistentKeepalive = 15", 1)
+			So(ioutil.WriteFile(pathAgu, []byte(modified), 0600), ShouldBeNil)
+
+			Convey("Of removed peers", func() {
+				conf.Peers = conf.Peers[:2]
+				err = RenderNetwork(conf, dirPeers, Options{})
+				So(err, ShouldHaveSameTypeAs, &ManualEditError{})
+				So(err.(*ManualEditError).Edits, ShouldHaveLength, 1)
+				So(err.(*ManualEditError).Edits[0].Path, ShouldEqual, "Agu/wg-example.conf")
+				So(err.(*ManualEditError).Edits[0].Rendered, ShouldBeNil)
+				current, err := ioutil.ReadFile(pathAgu)
+				So(err, ShouldBeNil)
+				So(string(current), ShouldEqual, modified)
+
+				So(RenderNetwork(conf, dirPeers, Options{Force: true}), ShouldBeNil)
+			})
+			Convey("Of removed networks", func() {
+				err = PruneNetworks(dirPeers, []string{"other"}, false)
+				So(err, ShouldHaveSameTypeAs, &ManualEditError{})
+				So(err.(*ManualEditError).Edits, ShouldHaveLength, 1)
+				_, err = os.Stat(path.Join(dirPeers, "Tento", "wg-example.conf"))
+				So(err, ShouldBeNil)
+
+				So(PruneNetworks(dirPeers, []string{"other"}, true), ShouldBeNil)
+			})
+			_, err = os.Stat(pathAgu)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+		Convey("Files of removed networks should be pruned", func() {
+			So((&DirSink{Dir: dirPeers}).Prune([]string{"other"}), ShouldBeNil)
+			_, err := os.Stat(path.Join(dirPeers, "Tento"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+
+			m, err := LoadManifest(dirPeers)
+			So(err, ShouldBeNil)
+			So(m.Networks, ShouldBeEmpty)
+		})
+	})
+}
+
+// renderPaths renders conf and checks the paths of the files rendered for each peer in paths, the files are returned by peer IDs.
+func renderPaths(conf *config.Config, paths map[string][]string) map[string][]RenderedFile {
+	configs, err := Render(conf, Options{})
+	So(err, ShouldBeNil)
+	files := map[string][]RenderedFile{}
+	for id, expected := range paths {
+		rendered := configs[id].Files
+		So(rendered, ShouldHaveLength, len(expected))
+		for i, f := range rendered {
+			So(f.Path, ShouldEqual, expected[i])
+		}
+		files[id] = rendered
+	}
+	return files
+}
+
+// renderWgQuick returns the wg-quick config of the peer rendered the same way as Render, but at now.
+func renderWgQuick(conf *config.Config, peerID string, now time.Time, opts Options) string {
+	descHash, err := descriptionHash(conf)
+	So(err, ShouldBeNil)
+	peer, ok := conf.GetPeerByID(peerID)
+	So(ok, ShouldBeTrue)
+	files, err := renderPeerFiles(conf, peer, now, opts, descHash)
+	So(err, ShouldBeNil)
+	So(files[0].Path, ShouldEndWith, ".conf")
+	return string(files[0].Content)
+}
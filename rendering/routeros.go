@@ -0,0 +1,21 @@
+package rendering
+
+import "strings"
+
+// RouterOSTag returns the comment tagging entries added by the script, which are removed before being added again.
+func (c *PeerConfigTplContext) RouterOSTag() string {
+	return c.RouterOSQuote("wg-make:" + c.Network.ID)
+}
+
+// RouterOSQuote returns value quoted for RouterOS scripts.
+func (c *PeerConfigTplContext) RouterOSQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(value) + `"`
+}
+
+// RouterOSMenu returns the menu of the IP version of an address in CIDR, i.e. /ip or /ipv6.
+func (c *PeerConfigTplContext) RouterOSMenu(cidr string) string {
+	if c.IsIPv6(cidr) {
+		return "/ipv6"
+	}
+	return "/ip"
+}
@@ -0,0 +1,123 @@
+package rendering
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+
+	"github.com/tevino/log"
+)
+
+// Sink receives the rendered files, e.g. a folder or an archive.
+type Sink interface {
+	// WriteNetwork receives all files rendered for a network at once.
+	WriteNetwork(networkID string, files []RenderedFile) error
+	// Prune removes the files of networks that are no longer among networkIDs, which are all networks rendered.
+	Prune(networkIDs []string) error
+}
+
+// DirSink writes rendered files into a peers folder.
+//
+// Unchanged files are left untouched, keeping the time of rendering recorded in them,
+// but they are rewritten if only the recorded hash of the network description changed.
+// Files previously rendered for the network but no longer needed are removed.
+// A *ManualEditError is returned without writing anything if any config to be overwritten or removed was modified manually,
+// unless Force is set.
+type DirSink struct {
+	Dir string
+	// Force overwrites configs modified manually.
+	Force bool
+}
+
+// WriteNetwork implements Sink.
+func (s *DirSink) WriteNetwork(networkID string, files []RenderedFile) error {
+	m, err := LoadManifest(s.Dir)
+	if err != nil {
+		return err
+	}
+	hashes := map[string]string{}
+	pending := []pendingFile{}
+	edits := []ManualEdit{}
+	for _, f := range files {
+		hash := contentHash(f.Content)
+		hashes[f.Path] = hash
+		filePath := path.Join(s.Dir, f.Path)
+		current, err := ioutil.ReadFile(filePath)
+		// The hash of the network description is updated even if nothing else changed, unlike the time of rendering.
+		if err == nil && contentHash(current) == hash {
+			if line := generatedLine(f.Content); recordsTime(line) || generatedLine(current) == line {
+				log.Debugf("Config is unchanged: %s\n", filePath)
+				continue
+			}
+		}
+		if err == nil && IsModifiedManually(current) {
+			if !s.Force {
+				edits = append(edits, ManualEdit{Path: f.Path, Current: current, Rendered: f.Content})
+				continue
+			}
+			log.Warnf("Overwriting config modified manually: %s\n", filePath)
+		}
+		log.Infof("Writing config: %s\n", filePath)
+		pending = append(pending, pendingFile{path: filePath, content: f.Content, mode: f.FileMode()})
+	}
+	stale := m.StaleFiles(networkID, files)
+	edits = append(edits, checkRemovals(s.Dir, stale, s.Force)...)
+	if len(edits) > 0 {
+		return &ManualEditError{Edits: edits}
+	}
+	// All configs of the network are swapped in at once, the previous ones are kept on any failure.
+	if err := writeFiles(pending); err != nil {
+		return fmt.Errorf("writing configs of network %s: %w", networkID, err)
+	}
+	if err := removeFiles(s.Dir, stale); err != nil {
+		return err
+	}
+	m.Networks[networkID] = hashes
+	return m.Save(s.Dir)
+}
+
+// Prune implements Sink, see PruneNetworks.
+func (s *DirSink) Prune(networkIDs []string) error {
+	return PruneNetworks(s.Dir, networkIDs, s.Force)
+}
+
+// TarSink writes rendered files into a tar archive.
+type TarSink struct {
+	w *tar.Writer
+}
+
+// NewTarSink returns a TarSink writing to w, it must be closed to finish the archive.
+func NewTarSink(w io.Writer) *TarSink {
+	return &TarSink{w: tar.NewWriter(w)}
+}
+
+// WriteNetwork implements Sink.
+func (s *TarSink) WriteNetwork(networkID string, files []RenderedFile) error {
+	for _, f := range files {
+		// The modification time is left zero to keep archives identical between renderings.
+		hdr := &tar.Header{
+			Name: f.Path,
+			Mode: int64(f.FileMode()),
+			Size: int64(len(f.Content)),
+		}
+		if err := s.w.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("writing header of %s: %w", f.Path, err)
+		}
+		if _, err := s.w.Write(f.Content); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// Prune implements Sink, archives only contain the files written, so there is nothing to remove.
+func (s *TarSink) Prune(networkIDs []string) error {
+	return nil
+}
+
+// Close finishes the archive, the underlying writer is not closed.
+func (s *TarSink) Close() error {
+	return s.w.Close()
+}
@@ -1,15 +1,172 @@
 package rendering
 
 import (
+	"crypto/sha1"
+	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/tevino/wg-make/config"
+	"github.com/tevino/wg-make/config/wireguard"
 )
 
 // PeerConfigTplContext contains context for peer configuration file rendering.
 type PeerConfigTplContext struct {
-	Network     *config.Network
-	GeneratedAt time.Time
-	Interface   *config.Peer
-	Peers       []config.Peer
+	Network *config.Network
+	// GeneratedAt is zero unless the time of rendering is recorded.
+	GeneratedAt     time.Time
+	DescriptionHash string
+	// Config is the resolved configuration of the peer, see BuildPeerConfig.
+	Config *wireguard.Config
+	// Peer is the description of the peer.
+	Peer *config.Peer
+}
+
+// InterfaceName returns the name of the WireGuard interface of the network, wg-<network> unless named by the peer.
+func (c *PeerConfigTplContext) InterfaceName() string {
+	if c.Peer != nil && c.Peer.InterfaceName != "" {
+		return c.Peer.InterfaceName
+	}
+	return wgInterfacePrefix + c.Network.ID
+}
+
+// DNSServers returns the addresses of DNS servers of the peer.
+func (c *PeerConfigTplContext) DNSServers() []string {
+	servers, _ := splitDNS(c.Config.Interface.DNS)
+	return servers
+}
+
+// DNSSearchDomains returns the search domains of the peer, which are accepted along with DNS servers by wg-quick.
+func (c *PeerConfigTplContext) DNSSearchDomains() []string {
+	_, domains := splitDNS(c.Config.Interface.DNS)
+	return domains
+}
+
+// splitDNS splits the DNS setting into addresses of servers and search domains.
+func splitDNS(dns string) (servers []string, domains []string) {
+	for _, item := range strings.Split(dns, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case net.ParseIP(item) != nil:
+			servers = append(servers, item)
+		default:
+			domains = append(domains, item)
+		}
+	}
+	return servers, domains
+}
+
+// SplitList returns the items of a comma-separated list, e.g. AllowedIPs.
+func (c *PeerConfigTplContext) SplitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Routes returns the unique AllowedIPs of all peers, which are routed to the WireGuard interface.
+func (c *PeerConfigTplContext) Routes() []string {
+	routes := []string{}
+	seen := map[string]bool{}
+	for _, p := range c.Config.Peers {
+		for _, route := range c.SplitList(p.AllowedIPs) {
+			if !seen[route] {
+				seen[route] = true
+				routes = append(routes, route)
+			}
+		}
+	}
+	return routes
+}
+
+// EndpointHost returns the host of an endpoint without brackets of IPv6 addresses.
+func (c *PeerConfigTplContext) EndpointHost(endpoint string) string {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return host
+}
+
+// EndpointPort returns the port of an endpoint.
+func (c *PeerConfigTplContext) EndpointPort(endpoint string) string {
+	_, port, _ := net.SplitHostPort(endpoint)
+	return port
+}
+
+// ShellQuote returns value in single quotes, which is accepted by UCI configs and the CLI of VyOS.
+func (c *PeerConfigTplContext) ShellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+// IsIP returns true if host is an IP address instead of a hostname.
+func (c *PeerConfigTplContext) IsIP(host string) bool {
+	return net.ParseIP(host) != nil
+}
+
+// IsIPv6 returns true if cidr is an IPv6 address or subnet.
+func (c *PeerConfigTplContext) IsIPv6(cidr string) bool {
+	ip, _, err := net.ParseCIDR(cidr)
+	return err == nil && ip.To4() == nil
+}
+
+// Hook is a PostUp hook paired with the PostDown hook undoing it.
+type Hook struct {
+	UpComment   string
+	Up          string
+	DownComment string
+	Down        string
+}
+
+// Hooks returns the PostUp and PostDown hooks of the peer in pairs, commented if they enable packet forwarding.
+func (c *PeerConfigTplContext) Hooks() []Hook {
+	iface := c.Config.Interface
+	n := len(iface.PostUps)
+	if len(iface.PostDowns) > n {
+		n = len(iface.PostDowns)
+	}
+	hooks := make([]Hook, n)
+	for i := range hooks {
+		if i < len(iface.PostUps) {
+			hooks[i].Up = iface.PostUps[i]
+		}
+		if i < len(iface.PostDowns) {
+			hooks[i].Down = iface.PostDowns[i]
+		}
+		if i < len(forwardingHookComments) {
+			hooks[i].UpComment = forwardingHookComments[i].up
+			hooks[i].DownComment = forwardingHookComments[i].down
+		}
+	}
+	return hooks
+}
+
+// nameUUID returns a name-based UUID as in RFC 4122 version 5, which stays the same between renderings.
+func nameUUID(name string) string {
+	sum := sha1.Sum([]byte("wg-make:" + name))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// RouteTable returns the routing table of routes to AllowedIPs for systemd-networkd, main if not specified like wg-quick.
+func (c *PeerConfigTplContext) RouteTable() string {
+	switch c.Config.Interface.Table {
+	case "", "auto":
+		return "main"
+	}
+	return c.Config.Interface.Table
+}
+
+// PrivateKeyPlaceholder is rendered in place of the private key for public-key-only peers.
+const PrivateKeyPlaceholder = "REPLACE_WITH_PRIVATE_KEY"
+
+// PrivateKeyPlaceholder returns the placeholder of the private key for public-key-only peers.
+func (c *PeerConfigTplContext) PrivateKeyPlaceholder() string {
+	return PrivateKeyPlaceholder
 }
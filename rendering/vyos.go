@@ -0,0 +1,25 @@
+package rendering
+
+import (
+	"fmt"
+	"hash/crc32"
+	"regexp"
+)
+
+var reVyOSInvalid = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// VyOSPeerName returns the name of a peer in the config of VyOS, which only allows letters, digits, hyphens and underscores.
+func (c *PeerConfigTplContext) VyOSPeerName(name string) string {
+	return reVyOSInvalid.ReplaceAllString(name, "_")
+}
+
+// VyOSNATRule returns the path of the source NAT rule of bounce servers, e.g. nat source rule 5095.
+//
+// The number of the rule is derived from the ID of the network so rules of networks on the same router don't collide.
+func (c *PeerConfigTplContext) VyOSNATRule() string {
+	nat := "nat"
+	if c.IsIPv6(c.Network.Subnet) {
+		nat = "nat66"
+	}
+	return fmt.Sprintf("%s source rule %d", nat, 5000+crc32.ChecksumIEEE([]byte(c.Network.ID))%1000)
+}